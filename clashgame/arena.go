@@ -0,0 +1,217 @@
+// arena.go
+package clashgame
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// CellPos is a grid cell in an arena file
+type CellPos struct {
+	Col int `json:"col"`
+	Row int `json:"row"`
+}
+
+// Bridge is a walkable crossing over the river, Width cells wide starting
+// at Col
+type Bridge struct {
+	Col   int `json:"col"`
+	Width int `json:"width"`
+}
+
+// DeployZone is a rectangle of cells a team may deploy troops and buildings
+// in. A zone with a Lane only opens once the enemy princess tower in that
+// lane has fallen, like the pocket behind a destroyed tower.
+type DeployZone struct {
+	Col  int `json:"col"`
+	Row  int `json:"row"`
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+	Lane int `json:"lane,omitempty"` // LaneLeft or LaneRight, 0 = always open
+}
+
+// Contains checks if a cell lies inside the zone
+func (z DeployZone) Contains(col, row int) bool {
+	return col >= z.Col && col < z.Col+z.Cols && row >= z.Row && row < z.Row+z.Rows
+}
+
+// TowerLayout places one team's crown towers. Princesses are listed left
+// lane first, matching Player.Buildings.
+type TowerLayout struct {
+	King       CellPos   `json:"king"`
+	Princesses []CellPos `json:"princesses"`
+}
+
+// Arena describes a map: its grid size, tilemap, river, bridges, where the
+// crown towers stand and where each team may deploy. Team 0 plays from the
+// top, team 1 from the bottom.
+type Arena struct {
+	Name          string          `json:"name"`
+	Columns       int             `json:"columns"`
+	Rows          int             `json:"rows"`
+	Tilemap       string          `json:"tilemap"` // CSV path, relative to the arena file
+	RiverStartRow int             `json:"riverStartRow"`
+	RiverEndRow   int             `json:"riverEndRow"`
+	Bridges       []Bridge        `json:"bridges"`
+	Towers        [2]TowerLayout  `json:"towers"`
+	DeployZones   [2][]DeployZone `json:"deployZones"`
+
+	Data    fs.FS  `json:"-"` // Data FS Path and Tilemap are in, nil for the embedded data
+	Path    string `json:"-"` // File the arena was loaded from, if any
+	SaveDir string `json:"-"` // Directory the editor saves Path and Tilemap under, "" if read-only
+}
+
+// DefaultArena returns the classic single-river arena with two bridges
+func DefaultArena() *Arena {
+	return &Arena{
+		Name:          "Classic",
+		Columns:       GridColumns,
+		Rows:          GridRows,
+		Tilemap:       "csv/tilemap.csv",
+		RiverStartRow: 30,
+		RiverEndRow:   33,
+		Bridges:       []Bridge{{Col: 5, Width: 4}, {Col: 27, Width: 4}},
+		Towers: [2]TowerLayout{
+			{King: CellPos{17, 5}, Princesses: []CellPos{{6, 12}, {28, 12}}},
+			{King: CellPos{17, 57}, Princesses: []CellPos{{6, 50}, {28, 50}}},
+		},
+		DeployZones: [2][]DeployZone{
+			{
+				{Col: 0, Row: 0, Cols: 36, Rows: 30},
+				{Col: 0, Row: 34, Cols: 18, Rows: 6, Lane: LaneLeft},
+				{Col: 18, Row: 34, Cols: 18, Rows: 6, Lane: LaneRight},
+			},
+			{
+				{Col: 0, Row: 34, Cols: 36, Rows: 30},
+				{Col: 0, Row: 24, Cols: 18, Rows: 6, Lane: LaneLeft},
+				{Col: 18, Row: 24, Cols: 18, Rows: 6, Lane: LaneRight},
+			},
+		},
+	}
+}
+
+// LoadArena reads an arena from a JSON file in a data FS. The tilemap path
+// is resolved against the arena file's directory.
+func LoadArena(fsys fs.FS, name string) (*Arena, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read arena: %v", err)
+	}
+
+	arena := &Arena{}
+	if err := json.Unmarshal(data, arena); err != nil {
+		return nil, fmt.Errorf("failed to parse arena %s: %v", name, err)
+	}
+
+	if arena.Tilemap != "" {
+		arena.Tilemap = path.Join(path.Dir(name), arena.Tilemap)
+	}
+
+	if err := arena.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid arena %s: %v", name, err)
+	}
+	arena.Data = fsys
+	arena.Path = name
+	return arena, nil
+}
+
+// data returns the FS the arena's files are in
+func (a *Arena) data() fs.FS {
+	if a.Data == nil {
+		return DefaultData()
+	}
+	return a.Data
+}
+
+// SaveArena writes an arena to a JSON file on disk, storing the tilemap path
+// relative to the arena's Path again
+func SaveArena(arena *Arena, filename string) error {
+	saved := *arena
+	if rel, err := filepath.Rel(path.Dir(arena.Path), arena.Tilemap); err == nil {
+		saved.Tilemap = filepath.ToSlash(rel)
+	}
+
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode arena: %v", err)
+	}
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write arena: %v", err)
+	}
+	return nil
+}
+
+// Validate checks that everything in the arena fits on its grid
+func (a *Arena) Validate() error {
+	if a.Columns <= 0 || a.Rows <= 0 {
+		return fmt.Errorf("grid must be at least 1x1, got %dx%d", a.Columns, a.Rows)
+	}
+	if a.Tilemap == "" {
+		return fmt.Errorf("no tilemap")
+	}
+	if a.RiverStartRow < 0 || a.RiverEndRow < a.RiverStartRow || a.RiverEndRow >= a.Rows {
+		return fmt.Errorf("river rows %d-%d outside the grid", a.RiverStartRow, a.RiverEndRow)
+	}
+
+	for i, bridge := range a.Bridges {
+		if bridge.Width <= 0 || bridge.Col < 0 || bridge.Col+bridge.Width > a.Columns {
+			return fmt.Errorf("bridge %d at col %d, width %d is outside the grid", i, bridge.Col, bridge.Width)
+		}
+	}
+
+	for team, towers := range a.Towers {
+		if !a.inGrid(towers.King) {
+			return fmt.Errorf("team %d king tower at (%d,%d) is outside the grid", team, towers.King.Col, towers.King.Row)
+		}
+		// Lanes map onto the two princess towers
+		if len(towers.Princesses) != 2 {
+			return fmt.Errorf("team %d needs 2 princess towers, got %d", team, len(towers.Princesses))
+		}
+		for _, cell := range towers.Princesses {
+			if !a.inGrid(cell) {
+				return fmt.Errorf("team %d princess tower at (%d,%d) is outside the grid", team, cell.Col, cell.Row)
+			}
+		}
+	}
+
+	for team, zones := range a.DeployZones {
+		if len(zones) == 0 {
+			return fmt.Errorf("team %d has no deploy zone", team)
+		}
+		for _, zone := range zones {
+			if zone.Cols <= 0 || zone.Rows <= 0 || !a.inGrid(CellPos{zone.Col, zone.Row}) ||
+				!a.inGrid(CellPos{zone.Col + zone.Cols - 1, zone.Row + zone.Rows - 1}) {
+				return fmt.Errorf("team %d deploy zone at (%d,%d) size %dx%d is outside the grid",
+					team, zone.Col, zone.Row, zone.Cols, zone.Rows)
+			}
+			if zone.Lane != 0 && zone.Lane != LaneLeft && zone.Lane != LaneRight {
+				return fmt.Errorf("team %d deploy zone has unknown lane %d", team, zone.Lane)
+			}
+		}
+	}
+	return nil
+}
+
+// inGrid checks if a cell lies on the arena grid
+func (a *Arena) inGrid(cell CellPos) bool {
+	return cell.Col >= 0 && cell.Col < a.Columns && cell.Row >= 0 && cell.Row < a.Rows
+}
+
+// CanDeployAt checks if a team may deploy on a cell right now. Pocket zones
+// count once the enemy princess tower in their lane is destroyed.
+func CanDeployAt(game *Game, team, col, row int) bool {
+	enemy := &game.Teams[EnemyTeam(team)]
+	for _, zone := range game.Arena.DeployZones[team] {
+		if !zone.Contains(col, row) {
+			continue
+		}
+		if zone.Lane == 0 || !enemy.Buildings[zone.Lane-1].Active {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+// balance.go
+package clashgame
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// CardBalance holds the derived combat stats of a troop or building card at
+// one level. Damage follows the same rules as the simulation, see
+// ShotDamage, and crown towers take the projectile's CrownTowerDamagePercent.
+type CardBalance struct {
+	Card          string   `json:"card"`
+	Kind          string   `json:"kind"` // "troop" or "building"
+	Elixir        int      `json:"elixir"`
+	Units         int      `json:"units"`
+	HitDamage     int      `json:"hitDamage"` // Per unit and hit
+	HitSpeed      float64  `json:"hitSpeed"`  // Seconds between hits
+	DPS           float64  `json:"dps"`       // All units together
+	DPSPerElixir  float64  `json:"dpsPerElixir"`
+	EffectiveHP   int      `json:"effectiveHP"` // All units plus their death spawns
+	HPPerElixir   float64  `json:"hpPerElixir"`
+	KingTTK       float64  `json:"kingTTK"`     // Seconds to destroy a king tower, 0 if it can't
+	PrincessTTK   float64  `json:"princessTTK"` // Seconds to destroy a princess tower, 0 if it can't
+	AttacksGround bool     `json:"attacksGround"`
+	AttacksAir    bool     `json:"attacksAir"`
+	Flying        bool     `json:"flying"`
+	Splash        bool     `json:"splash"`
+	Outliers      []string `json:"outliers,omitempty"` // Filled in by FlagBalanceOutliers
+}
+
+// Coverage describes which units the card can hit
+func (b *CardBalance) Coverage() string {
+	switch {
+	case b.AttacksAir && b.AttacksGround:
+		return "air+ground"
+	case b.AttacksAir:
+		return "air"
+	case b.AttacksGround:
+		return "ground"
+	default:
+		return "none"
+	}
+}
+
+// AnalyzeBalance computes the balance stats of every troop and building card
+// in the catalog with cards at level and crown towers at kingLevel. Spells
+// are skipped. The result is sorted by card name.
+func AnalyzeBalance(catalog *Catalog, level, kingLevel int) []CardBalance {
+	towerMultiplier := TowerLevelMultiplier(kingLevel)
+	kingHP := float64(ScaleStat(kingTowerHitpoints, towerMultiplier))
+	princessHP := float64(ScaleStat(princessTowerHitpoints, towerMultiplier))
+
+	var cards []CardBalance
+	for _, name := range catalog.CardNames() {
+		card, _ := catalog.Card(name)
+
+		var b CardBalance
+		var towerHit float64
+		if troop, exists := catalog.CardTroop(name); exists {
+			b, towerHit = troopBalance(catalog, troop, level)
+			b.Units = max(card.Count, 1)
+		} else if building, exists := catalog.Building(card.Building); exists {
+			b = buildingBalance(catalog, building, level)
+			b.Units = 1
+		} else {
+			continue
+		}
+		b.Card = name
+		b.Elixir = card.ElixirCost
+
+		if b.HitSpeed > 0 {
+			b.DPS = float64(b.Units*b.HitDamage) / b.HitSpeed
+			// Crown towers only ever face troops
+			if towerHit > 0 {
+				towerDPS := float64(b.Units) * towerHit / b.HitSpeed
+				b.KingTTK = kingHP / towerDPS
+				b.PrincessTTK = princessHP / towerDPS
+			}
+		}
+		b.EffectiveHP *= b.Units
+		if b.Elixir > 0 {
+			b.DPSPerElixir = b.DPS / float64(b.Elixir)
+			b.HPPerElixir = float64(b.EffectiveHP) / float64(b.Elixir)
+		}
+		cards = append(cards, b)
+	}
+	return cards
+}
+
+// troopBalance fills in one unit of a troop card. It also returns the damage
+// a hit deals to a crown tower, 0 when the troop never attacks buildings.
+func troopBalance(catalog *Catalog, troop *TroopTemplate, level int) (CardBalance, float64) {
+	multiplier := LevelMultiplier(troop.Rarity, ClampCardLevel(troop.Rarity, level, MaxCardLevel))
+	damage, towerHit := hitDamage(catalog, ScaleStat(troop.Damage, multiplier), multiplier, troop.Projectile.Name)
+
+	b := CardBalance{
+		Kind:          "troop",
+		HitDamage:     damage,
+		HitSpeed:      troop.HitSpeed,
+		EffectiveHP:   ScaleStat(troop.Hitpoints, multiplier),
+		AttacksGround: troop.AttacksGround,
+		AttacksAir:    troop.AttacksAir,
+		Flying:        troop.FlyingHeight > 0,
+		Splash:        troop.AreaDamageRadius > 0 || troop.Projectile.Radius > 0,
+	}
+
+	// Units left behind on death soak up damage too
+	if spawn, exists := catalog.Troop(troop.DeathSpawnCharacter); exists && troop.DeathSpawnCount > 0 {
+		spawnMultiplier := LevelMultiplier(spawn.Rarity, ClampCardLevel(spawn.Rarity, level, MaxCardLevel))
+		b.EffectiveHP += troop.DeathSpawnCount * ScaleStat(spawn.Hitpoints, spawnMultiplier)
+	}
+
+	if troop.TargetOnlyTroops || !troop.AttacksGround {
+		towerHit = 0
+	}
+	return b, towerHit
+}
+
+// buildingBalance fills in a building card
+func buildingBalance(catalog *Catalog, building *BuildingTemplate, level int) CardBalance {
+	multiplier := LevelMultiplier(building.Rarity, ClampCardLevel(building.Rarity, level, MaxCardLevel))
+
+	// Buildings without a projectile fire the "normal" tower shot
+	projectile := building.Projectile
+	if projectile == "" {
+		projectile = "normal"
+	}
+	damage, _ := hitDamage(catalog, ScaleStat(building.Damage, multiplier), multiplier, projectile)
+
+	b := CardBalance{
+		Kind:          "building",
+		HitDamage:     damage,
+		HitSpeed:      building.HitSpeed,
+		EffectiveHP:   ScaleStat(building.Hitpoints, multiplier),
+		AttacksGround: building.AttacksGround,
+		AttacksAir:    building.AttacksAir,
+	}
+	if template, exists := catalog.Projectile(projectile); exists {
+		b.Splash = template.Radius > 0
+	}
+	return b
+}
+
+// hitDamage returns the damage one hit deals to units and to crown towers
+// for a unit with the given damage and level multiplier firing the named
+// projectile
+func hitDamage(catalog *Catalog, damage int, multiplier float64, projectile string) (int, float64) {
+	template, exists := catalog.Projectile(projectile)
+	if !exists {
+		return damage, float64(damage)
+	}
+	damage = ShotDamage(template, damage, multiplier)
+	return damage, float64(damage) * (100 + template.CrownTowerDamagePercent) / 100
+}
+
+// FlagBalanceOutliers marks cards whose DPS per elixir or HP per elixir lies
+// more than threshold standard deviations from the average of all cards.
+// Cards that deal no damage are left out of the DPS comparison.
+func FlagBalanceOutliers(cards []CardBalance, threshold float64) {
+	flagOutliers(cards, threshold, "DPS/elixir", func(b *CardBalance) (float64, bool) {
+		return b.DPSPerElixir, b.DPS > 0
+	})
+	flagOutliers(cards, threshold, "HP/elixir", func(b *CardBalance) (float64, bool) {
+		return b.HPPerElixir, b.Elixir > 0
+	})
+}
+
+// flagOutliers flags one stat by its z-score
+func flagOutliers(cards []CardBalance, threshold float64, stat string, value func(*CardBalance) (float64, bool)) {
+	var sum, sumSquares float64
+	count := 0
+	for i := range cards {
+		if v, ok := value(&cards[i]); ok {
+			sum += v
+			sumSquares += v * v
+			count++
+		}
+	}
+	if count < 2 {
+		return
+	}
+	mean := sum / float64(count)
+	stddev := math.Sqrt(sumSquares/float64(count) - mean*mean)
+	if stddev == 0 {
+		return
+	}
+
+	for i := range cards {
+		v, ok := value(&cards[i])
+		if !ok {
+			continue
+		}
+		if z := (v - mean) / stddev; math.Abs(z) > threshold {
+			direction := "above"
+			if z < 0 {
+				direction = "below"
+			}
+			cards[i].Outliers = append(cards[i].Outliers,
+				fmt.Sprintf("%s %.1f is %.1f sd %s average %.1f", stat, v, math.Abs(z), direction, mean))
+		}
+	}
+}
+
+// BalanceDelta is one stat a balance patch changed
+type BalanceDelta struct {
+	Card    string  `json:"card"`
+	Stat    string  `json:"stat"` // Stat name, or "added"/"removed" for whole cards
+	Old     float64 `json:"old"`
+	New     float64 `json:"new"`
+	Percent float64 `json:"percent"` // Change relative to Old, 0 when Old is 0
+}
+
+// balanceStats are the stats DiffBalance compares
+var balanceStats = []struct {
+	name  string
+	value func(*CardBalance) float64
+}{
+	{"elixir", func(b *CardBalance) float64 { return float64(b.Elixir) }},
+	{"units", func(b *CardBalance) float64 { return float64(b.Units) }},
+	{"hitDamage", func(b *CardBalance) float64 { return float64(b.HitDamage) }},
+	{"hitSpeed", func(b *CardBalance) float64 { return b.HitSpeed }},
+	{"dps", func(b *CardBalance) float64 { return b.DPS }},
+	{"dpsPerElixir", func(b *CardBalance) float64 { return b.DPSPerElixir }},
+	{"effectiveHP", func(b *CardBalance) float64 { return float64(b.EffectiveHP) }},
+	{"hpPerElixir", func(b *CardBalance) float64 { return b.HPPerElixir }},
+	{"kingTTK", func(b *CardBalance) float64 { return b.KingTTK }},
+	{"princessTTK", func(b *CardBalance) float64 { return b.PrincessTTK }},
+	{"attacksGround", func(b *CardBalance) float64 { return boolStat(b.AttacksGround) }},
+	{"attacksAir", func(b *CardBalance) float64 { return boolStat(b.AttacksAir) }},
+}
+
+// boolStat turns a flag into 0 or 1 so it can be diffed like a number
+func boolStat(flag bool) float64 {
+	if flag {
+		return 1
+	}
+	return 0
+}
+
+// FormatStat writes a stat with three decimals, as the balance-report and
+// arena-sim CSV reports print their numbers
+func FormatStat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 3, 64)
+}
+
+// DiffBalance lists every stat that differs between two analyses of the
+// same cards, for example before and after a CSV change. Cards only in one
+// of them are reported as added or removed.
+func DiffBalance(old, new []CardBalance) []BalanceDelta {
+	oldCards := make(map[string]*CardBalance, len(old))
+	for i := range old {
+		oldCards[old[i].Card] = &old[i]
+	}
+	newCards := make(map[string]*CardBalance, len(new))
+	for i := range new {
+		newCards[new[i].Card] = &new[i]
+	}
+
+	var deltas []BalanceDelta
+	for i := range new {
+		after := &new[i]
+		before, exists := oldCards[after.Card]
+		if !exists {
+			deltas = append(deltas, BalanceDelta{Card: after.Card, Stat: "added"})
+			continue
+		}
+		for _, stat := range balanceStats {
+			oldValue, newValue := stat.value(before), stat.value(after)
+			if math.Abs(newValue-oldValue) < 1e-9 {
+				continue
+			}
+			delta := BalanceDelta{Card: after.Card, Stat: stat.name, Old: oldValue, New: newValue}
+			if oldValue != 0 {
+				delta.Percent = (newValue - oldValue) / oldValue * 100
+			}
+			deltas = append(deltas, delta)
+		}
+	}
+	for i := range old {
+		if _, exists := newCards[old[i].Card]; !exists {
+			deltas = append(deltas, BalanceDelta{Card: old[i].Card, Stat: "removed"})
+		}
+	}
+
+	sort.SliceStable(deltas, func(i, j int) bool {
+		return deltas[i].Card < deltas[j].Card
+	})
+	return deltas
+}
@@ -0,0 +1,520 @@
+// bot.go
+package clashgame
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// BotDifficulty selects how quickly and how well a bot plays
+type BotDifficulty int
+
+const (
+	BotEasy BotDifficulty = iota
+	BotMedium
+	BotHard
+)
+
+// String returns the difficulty's name
+func (d BotDifficulty) String() string {
+	switch d {
+	case BotEasy:
+		return "easy"
+	case BotMedium:
+		return "medium"
+	case BotHard:
+		return "hard"
+	default:
+		return fmt.Sprintf("difficulty(%d)", int(d))
+	}
+}
+
+// ParseBotDifficulty reads a difficulty name like "hard"
+func ParseBotDifficulty(name string) (BotDifficulty, error) {
+	for _, d := range []BotDifficulty{BotEasy, BotMedium, BotHard} {
+		if strings.EqualFold(name, d.String()) {
+			return d, nil
+		}
+	}
+	return BotEasy, fmt.Errorf("unknown bot difficulty %q", name)
+}
+
+// botSettings tune a difficulty level
+type botSettings struct {
+	thinkInterval int     // Ticks between decisions
+	pushElixir    float64 // Elixir saved up before starting a push
+	counters      bool    // Picks counters by unit type instead of any card
+	supportPushes bool    // Follows its own tanks with support troops
+	mistakeChance float64 // Chance to overlook a threat on a decision
+}
+
+var botDifficulties = map[BotDifficulty]botSettings{
+	BotEasy:   {thinkInterval: 50, pushElixir: 10, mistakeChance: 0.35},
+	BotMedium: {thinkInterval: 25, pushElixir: 8, counters: true, mistakeChance: 0.1},
+	BotHard:   {thinkInterval: 10, pushElixir: 7, counters: true, supportPushes: true},
+}
+
+// DefaultBotDeck is dealt to bots whose player didn't bring a deck
+var DefaultBotDeck = []string{
+	"Giant", "Musketeer", "Knight", "Archers",
+	"Minions", "Arrows", "Skeletons", "Cannon",
+}
+
+// Bot plays one player through the command API, like a human player would.
+// It defends pushes near its team's towers with counters picked by unit
+// type and builds pushes behind its team's tanks. With a nil Random every
+// decision depends on the game state only, so matches replay exactly.
+type Bot struct {
+	Player     int // Player the bot plays
+	Team       int // The player's team, see PlayerTeam
+	Difficulty BotDifficulty
+	Random     *rand.Rand
+
+	nextThink int // Tick of the next decision
+}
+
+// NewBot creates a bot for a player whose choices vary with the seed
+func NewBot(player int, difficulty BotDifficulty, seed int64) *Bot {
+	return &Bot{Player: player, Team: PlayerTeam(player), Difficulty: difficulty, Random: rand.New(rand.NewSource(seed))}
+}
+
+// NewDeterministicBot creates a bot for a player that never makes random
+// choices
+func NewDeterministicBot(player int, difficulty BotDifficulty) *Bot {
+	return &Bot{Player: player, Team: PlayerTeam(player), Difficulty: difficulty}
+}
+
+// AddBot lets a bot play a player. A player without a deck gets
+// DefaultBotDeck at the tournament level.
+func (g *Game) AddBot(bot *Bot) {
+	player := &g.Players[bot.Player]
+	if !player.HasHand() {
+		for _, name := range DefaultBotDeck {
+			player.Deck = append(player.Deck, DeckCard{Name: name, Level: TournamentLevelCap})
+		}
+		player.dealHand()
+	}
+	g.Bots = append(g.Bots, bot)
+}
+
+// UpdateBots gives every bot a chance to act. It runs at the end of a tick,
+// so bots see the finished state and their commands apply on the next tick.
+func UpdateBots(game *Game) {
+	for _, bot := range game.Bots {
+		bot.Think(game)
+	}
+}
+
+// botCard summarizes what a card is good at
+type botCard struct {
+	Name     string
+	Cost     int
+	Spell    bool
+	Building bool
+	Units    int
+	Health   int     // Hitpoints of one unit
+	DPS      float64 // Damage per second of all units together
+	HitsAir  bool
+	Splash   bool
+	Ranged   bool
+	Tank     bool
+}
+
+// profileCard looks up what a card does from its templates
+func profileCard(catalog *Catalog, name string) (botCard, bool) {
+	card, exists := catalog.Card(name)
+	if !exists {
+		return botCard{}, false
+	}
+	profile := botCard{Name: name, Cost: card.ElixirCost, Units: max(card.Count, 1)}
+
+	switch {
+	case card.IsSpell():
+		projectile, exists := catalog.Projectile(card.Spell)
+		if !exists {
+			return botCard{}, false
+		}
+		profile.Spell = true
+		profile.HitsAir = true
+		profile.Splash = projectile.Radius > 0
+	case card.IsBuilding():
+		building, exists := catalog.Building(card.Building)
+		if !exists {
+			return botCard{}, false
+		}
+		profile.Building = true
+		profile.Health = building.Hitpoints
+		profile.HitsAir = building.AttacksAir
+		if building.HitSpeed > 0 {
+			profile.DPS = float64(building.Damage) / building.HitSpeed
+		}
+	default:
+		troop, exists := catalog.CardTroop(name)
+		if !exists {
+			return botCard{}, false
+		}
+		damage := troop.Damage
+		if damage == 0 {
+			damage = troop.Projectile.Damage
+		}
+		profile.Health = troop.Hitpoints
+		if troop.HitSpeed > 0 {
+			profile.DPS = float64(damage) / troop.HitSpeed * float64(profile.Units)
+		}
+		profile.HitsAir = troop.AttacksAir
+		profile.Splash = troop.AreaDamageRadius > 0 || troop.Projectile.Radius > 0
+		profile.Ranged = troop.Range > TilesToCells(3)
+		profile.Tank = troop.TargetOnlyBuildings || troop.Hitpoints >= tankHitpoints
+	}
+	return profile, true
+}
+
+// tankHitpoints is the unit health from which a troop counts as a tank
+const tankHitpoints = 1500
+
+// botThreat is the enemy force pushing down one lane
+type botThreat struct {
+	Lane         int
+	Count        int
+	Health       int
+	FlyingHealth int
+	MaxHealth    int
+	Front        Position // Enemy closest to the bot's king
+}
+
+// Swarm reports whether the threat is many weak units
+func (t botThreat) Swarm() bool {
+	return t.Count >= 3 && t.Health/t.Count < 400
+}
+
+// Flying reports whether most of the threat is in the air
+func (t botThreat) Flying() bool {
+	return t.FlyingHealth*2 > t.Health
+}
+
+// Think makes at most one decision: defend the biggest threat if there is
+// one, otherwise build or support a push
+func (b *Bot) Think(game *Game) {
+	if game.GameTime < b.nextThink || game.Phase == PhaseEnded {
+		return
+	}
+	settings := botDifficulties[b.Difficulty]
+	b.nextThink = game.GameTime + settings.thinkInterval
+
+	hand := b.affordableHand(game)
+	if len(hand) == 0 {
+		return
+	}
+
+	if threat, found := b.biggestThreat(game); found && !b.chance(settings.mistakeChance) {
+		if b.defend(game, threat, hand, settings) {
+			return
+		}
+	}
+	b.push(game, hand, settings)
+}
+
+// affordableHand profiles the cards in hand the bot has elixir for
+func (b *Bot) affordableHand(game *Game) []botCard {
+	player := &game.Players[b.Player]
+	var hand []botCard
+	for _, name := range player.Hand {
+		card, known := profileCard(game.Catalog, name)
+		if known && float64(card.Cost) <= player.Elixir {
+			hand = append(hand, card)
+		}
+	}
+	// Hand order changes as cards cycle; sort so ties break the same way
+	sort.Slice(hand, func(i, j int) bool { return hand[i].Name < hand[j].Name })
+	return hand
+}
+
+// biggestThreat groups enemy troops on the bot's side of the river, or close
+// to its towers, by lane and returns the lane with the most health
+func (b *Bot) biggestThreat(game *Game) (botThreat, bool) {
+	threats := map[int]*botThreat{}
+	ownKing := game.Teams[b.Team].KingBuilding.Position
+	reach := 10 * game.Grid.CellWidth
+
+	for i := range game.Troops {
+		troop := &game.Troops[i]
+		if !troop.Active || troop.Team == b.Team {
+			continue
+		}
+		if !b.onOwnSide(game, troop.Position) && !b.nearOwnTower(game, troop.Position, reach) {
+			continue
+		}
+
+		lane := laneForPosition(game, troop.Position)
+		threat := threats[lane]
+		if threat == nil {
+			threat = &botThreat{Lane: lane, Front: troop.Position}
+			threats[lane] = threat
+		}
+		threat.Count++
+		threat.Health += troop.Health
+		threat.MaxHealth = max(threat.MaxHealth, troop.Health)
+		if IsFlyingTroop(troop) {
+			threat.FlyingHealth += troop.Health
+		}
+		if Distance(troop.Position, ownKing) < Distance(threat.Front, ownKing) {
+			threat.Front = troop.Position
+		}
+	}
+
+	var biggest *botThreat
+	for _, lane := range []int{LaneLeft, LaneRight} {
+		if threat := threats[lane]; threat != nil && (biggest == nil || threat.Health > biggest.Health) {
+			biggest = threat
+		}
+	}
+	if biggest == nil {
+		return botThreat{}, false
+	}
+	return *biggest, true
+}
+
+// defend answers a threat with the best counter in hand
+func (b *Bot) defend(game *Game, threat botThreat, hand []botCard, settings botSettings) bool {
+	var best *botCard
+	bestScore := 0.0
+	for i := range hand {
+		score := 1.0
+		if settings.counters {
+			score = counterScore(hand[i], threat)
+		} else if hand[i].Spell {
+			// Without counter play spells are never worth it
+			continue
+		}
+		if score <= 0 {
+			continue
+		}
+		if b.Random != nil {
+			score += b.Random.Float64() * 0.5
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = &hand[i], score
+		}
+	}
+	if best == nil {
+		return false
+	}
+
+	frontCol, frontRow := game.Grid.PositionToCell(threat.Front)
+	switch {
+	case best.Spell:
+		return b.deploy(game, best.Name, frontCol, frontRow)
+	case best.Building:
+		// Buildings go in the middle of the bot's side to pull attackers
+		return b.deploy(game, best.Name, game.Grid.Columns/2, b.rowFromRiver(game, 7))
+	default:
+		// Land between the threat and the tower, ranged units further back
+		back := 3
+		if best.Ranged {
+			back = 5
+		}
+		return b.deploy(game, best.Name, frontCol, frontRow-b.forward()*back)
+	}
+}
+
+// counterScore rates how well a card answers a threat; 0 or less means it
+// shouldn't be used at all
+func counterScore(card botCard, threat botThreat) float64 {
+	if card.Spell {
+		if threat.Swarm() && card.Splash {
+			return 4 + float64(threat.Count)*0.5
+		}
+		return 0
+	}
+	if threat.Flying() && !card.HitsAir {
+		return 0
+	}
+
+	score := 1.0
+	switch {
+	case threat.Swarm():
+		if card.Splash {
+			score += 3
+		}
+		if card.Units >= 3 {
+			score--
+		}
+	case threat.MaxHealth >= tankHitpoints:
+		if card.Units >= 3 || card.Building {
+			score += 2
+		}
+		score += card.DPS / 100
+	default:
+		score += float64(card.Health)/500 + card.DPS/100
+	}
+	return score - float64(card.Cost)*0.2
+}
+
+// push starts a push with a tank, backs up a tank already on the field, or
+// spends elixir that would otherwise be wasted
+func (b *Bot) push(game *Game, hand []botCard, settings botSettings) {
+	player := &game.Players[b.Player]
+	lane := b.attackLane(game)
+
+	if settings.supportPushes {
+		if tank := b.ownTank(game); tank != nil {
+			for _, card := range hand {
+				if card.Tank || card.Spell || card.Building || player.Elixir < float64(card.Cost)+1 {
+					continue
+				}
+				col, row := game.Grid.PositionToCell(tank.Position)
+				if b.deploy(game, card.Name, col, row-b.forward()*4) {
+					return
+				}
+			}
+		}
+	}
+
+	if player.Elixir >= settings.pushElixir {
+		for _, card := range hand {
+			if card.Tank && b.deployInLane(game, card.Name, lane) {
+				return
+			}
+		}
+	}
+
+	// Don't sit on full elixir; send the cheapest unit to the bridge
+	if player.Elixir >= float64(player.ElixirMax)-0.5 {
+		var cheapest *botCard
+		for i := range hand {
+			if !hand[i].Spell && (cheapest == nil || hand[i].Cost < cheapest.Cost) {
+				cheapest = &hand[i]
+			}
+		}
+		if cheapest != nil {
+			b.deploy(game, cheapest.Name, b.bridgeCol(game, lane), b.rowFromRiver(game, 2))
+		}
+	}
+}
+
+// deployInLane puts a card at the back of the bot's side behind the lane's
+// princess tower, so a push has time to build up
+func (b *Bot) deployInLane(game *Game, name string, lane int) bool {
+	tower := game.Teams[b.Team].Buildings[lane-1].Position
+	col, _ := game.Grid.PositionToCell(tower)
+	_, kingRow := game.Grid.PositionToCell(game.Teams[b.Team].KingBuilding.Position)
+	return b.deploy(game, name, col, kingRow)
+}
+
+// deploy submits a card at the valid cell closest to the wanted one
+func (b *Bot) deploy(game *Game, name string, col, row int) bool {
+	cmdType := CommandDeployCard
+	if card, _ := game.Catalog.Card(name); card.IsSpell() {
+		cmdType = CommandCastSpell
+	}
+
+	for radius := 0; radius <= 12; radius++ {
+		for r := row - radius; r <= row+radius; r++ {
+			for c := col - radius; c <= col+radius; c++ {
+				// Only look at the ring at this radius
+				if r != row-radius && r != row+radius && c != col-radius && c != col+radius {
+					continue
+				}
+				cmd := Command{Type: cmdType, Player: b.Player, Card: name, Col: c, Row: r}
+				if ValidateCommand(game, cmd) == nil {
+					game.SubmitCommand(cmd)
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// attackLane picks the lane whose enemy princess tower is weakest
+func (b *Bot) attackLane(game *Game) int {
+	enemy := &game.Teams[EnemyTeam(b.Team)]
+	lane := LaneLeft
+	weakest := math.MaxInt
+	for i, tower := range enemy.Buildings {
+		health := tower.Health
+		if !tower.Active {
+			health = 0
+		}
+		if health < weakest {
+			lane, weakest = i+1, health
+		}
+	}
+	return lane
+}
+
+// ownTank returns the bot's tank furthest up the field, if any
+func (b *Bot) ownTank(game *Game) *Troop {
+	var tank *Troop
+	for i := range game.Troops {
+		troop := &game.Troops[i]
+		if !troop.Active || troop.Team != b.Team || troop.IsDeploying() {
+			continue
+		}
+		if !TargetsOnlyBuildings(troop) && troop.MaxHealth < tankHitpoints {
+			continue
+		}
+		if tank == nil || float64(b.forward())*(troop.Position.Y-tank.Position.Y) > 0 {
+			tank = troop
+		}
+	}
+	return tank
+}
+
+// forward is the row direction toward the enemy: down for the top team
+func (b *Bot) forward() int {
+	if b.Team == 0 {
+		return 1
+	}
+	return -1
+}
+
+// rowFromRiver returns the row the given number of rows back from the river
+// on the bot's side
+func (b *Bot) rowFromRiver(game *Game, rows int) int {
+	if b.Team == 0 {
+		return game.Grid.RiverStartRow - 1 - rows
+	}
+	return game.Grid.RiverEndRow + 1 + rows
+}
+
+// bridgeCol returns the center column of the bridge closest to a lane
+func (b *Bot) bridgeCol(game *Game, lane int) int {
+	tower := game.Teams[b.Team].Buildings[lane-1].Position
+	target := findNearestBridge(game, tower)
+	col, _ := game.Grid.PositionToCell(target)
+	return col
+}
+
+// onOwnSide checks if a position is on the bot's half of the arena,
+// counting the river
+func (b *Bot) onOwnSide(game *Game, pos Position) bool {
+	_, row := game.Grid.PositionToCell(pos)
+	if b.Team == 0 {
+		return row <= game.Grid.RiverEndRow
+	}
+	return row >= game.Grid.RiverStartRow
+}
+
+// nearOwnTower checks if a position is within reach of one of the bot's
+// standing towers
+func (b *Bot) nearOwnTower(game *Game, pos Position, reach float64) bool {
+	towers := &game.Teams[b.Team]
+	if towers.KingBuilding.Active && Distance(pos, towers.KingBuilding.Position) <= reach {
+		return true
+	}
+	for _, tower := range towers.Buildings {
+		if tower.Active && Distance(pos, tower.Position) <= reach {
+			return true
+		}
+	}
+	return false
+}
+
+// chance rolls a probability; deterministic bots never take chances
+func (b *Bot) chance(probability float64) bool {
+	return b.Random != nil && b.Random.Float64() < probability
+}
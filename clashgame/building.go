@@ -0,0 +1,211 @@
+// building.go
+package clashgame
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+)
+
+// BuildingTemplate holds the stats of a deployable building from
+// buildings.csv
+type BuildingTemplate struct {
+	Name            string  `csv:"Name"`
+	Rarity          string  `csv:"Rarity"`
+	Hitpoints       int     `csv:"Hitpoints"`
+	Damage          int     `csv:"Damage"`
+	HitSpeed        float64 `csv:"HitSpeed" unit:"ms"`    // Seconds between attacks
+	Range           float64 `csv:"Range" unit:"distance"` // Range in grid cells
+	DeployTime      float64 `csv:"DeployTime" unit:"ms"`  // Seconds
+	LifeTime        float64 `csv:"LifeTime" unit:"ms"`    // Seconds before the building expires (0 = forever)
+	Projectile      string  `csv:"Projectile"`
+	AttacksGround   bool    `csv:"AttacksGround"`
+	AttacksAir      bool    `csv:"AttacksAir"`
+	CollisionRadius float64 `csv:"CollisionRadius" unit:"distance"` // Radius in grid cells
+	SizeInCells     float64 // Square footprint side in grid cells
+}
+
+// defaultBuildings are used when the building CSV can't be loaded
+var defaultBuildings = map[string]*BuildingTemplate{
+	"Cannon": {
+		Name:          "Cannon",
+		Rarity:        "Common",
+		Hitpoints:     322,
+		Damage:        83,
+		HitSpeed:      0.9,
+		Range:         TilesToCells(5.5),
+		DeployTime:    1.0,
+		LifeTime:      30.0,
+		AttacksGround: true,
+		SizeInCells:   3,
+	},
+	"Tesla": {
+		Name:          "Tesla",
+		Rarity:        "Common",
+		Hitpoints:     450,
+		Damage:        90,
+		HitSpeed:      1.2,
+		Range:         TilesToCells(5.5),
+		DeployTime:    1.0,
+		LifeTime:      30.0,
+		AttacksGround: true,
+		AttacksAir:    true,
+		SizeInCells:   2,
+	},
+}
+
+// defaultBuildingTemplates returns a copy of the built-in buildings
+func defaultBuildingTemplates() map[string]*BuildingTemplate {
+	buildings := make(map[string]*BuildingTemplate, len(defaultBuildings))
+	for name, template := range defaultBuildings {
+		copied := *template
+		buildings[name] = &copied
+	}
+	return buildings
+}
+
+// LoadBuildingTemplates loads building templates from a CSV file in fsys
+func LoadBuildingTemplates(fsys fs.FS, path string) (map[string]*BuildingTemplate, error) {
+	table, err := ReadCSVTable(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	buildings := make(map[string]*BuildingTemplate)
+	var errs []error
+	for i, record := range table.Rows {
+		if skipCSVRow(record) {
+			continue
+		}
+
+		template := &BuildingTemplate{}
+		if err := table.Unmarshal(i, template); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// The square footprint covers the collision circle
+		template.SizeInCells = math.Max(2, math.Ceil(2*template.CollisionRadius))
+		buildings[template.Name] = template
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if len(buildings) == 0 {
+		return nil, fmt.Errorf("no valid building templates found in CSV")
+	}
+	return buildings, nil
+}
+
+// NewDeployedBuilding creates a building from a template at the given card
+// level, centered on a position
+func NewDeployedBuilding(template *BuildingTemplate, pos Position, team, level int, grid *GridSystem) *Building {
+	level = ClampCardLevel(template.Rarity, level, MaxCardLevel)
+	multiplier := LevelMultiplier(template.Rarity, level)
+
+	building := NewBuilding(
+		pos.X,
+		pos.Y,
+		ScaleStat(template.Hitpoints, multiplier),
+		ScaleStat(template.Damage, multiplier),
+		template.Range,
+		GetTroopColorByTeam(team, template.Rarity),
+		template.SizeInCells,
+		template.SizeInCells,
+		grid,
+	)
+	building.Name = template.Name
+	building.Team = team
+	building.GroundOnly = !template.AttacksAir
+	building.ProjectileType = template.Projectile
+	building.DamageMultiplier = multiplier
+	if template.HitSpeed > 0 {
+		building.AttackDelay = SecondsToTicks(template.HitSpeed)
+	}
+	building.LifeTicks = SecondsToTicks(template.LifeTime)
+	building.DeployTicks = SecondsToTicks(template.DeployTime)
+
+	return &building
+}
+
+// PlaceBuilding deploys a player's building card on a cell. The footprint
+// must be free; once placed it blocks the grid until the building dies.
+func PlaceBuilding(g *Game, name string, col, row, player, level int) error {
+	template, exists := g.Catalog.Building(name)
+	if !exists {
+		return fmt.Errorf("building template not found: %s", name)
+	}
+
+	team := g.Players[player].Team
+	building := NewDeployedBuilding(template, g.Grid.CellToPosition(col, row), team, level, g.Grid)
+	building.Owner = player
+	if !g.Grid.IsFootprintFree(building) {
+		return fmt.Errorf("no room for %s at (%d,%d)", name, col, row)
+	}
+
+	building.ID = g.NextBuildingID
+	g.NextBuildingID++
+	g.BuildingMap[building.ID] = building
+	g.DeployedBuildings = append(g.DeployedBuildings, building)
+	g.Grid.AddObstacle(building)
+
+	logf("Player %d placed %s for team %d (Building ID=%d)\n", player, name, team, building.ID)
+	return nil
+}
+
+// UpdateBuildings expires deployed buildings whose LifeTime ran out, frees
+// the grid cells of destroyed buildings and drops dead deployed buildings,
+// so troops re-path through the gap
+func UpdateBuildings(game *Game) {
+	for i := range game.Teams {
+		towers := &game.Teams[i]
+		if !towers.KingBuilding.Active {
+			game.Grid.RemoveObstacle(&towers.KingBuilding.Building)
+		}
+		for j := range towers.Buildings {
+			if !towers.Buildings[j].Active {
+				game.Grid.RemoveObstacle(&towers.Buildings[j])
+			}
+		}
+	}
+
+	remaining := game.DeployedBuildings[:0]
+	for _, building := range game.DeployedBuildings {
+		if building.Active && !building.IsDeploying() && building.LifeTicks > 0 {
+			building.LifeTicks--
+			if building.LifeTicks == 0 {
+				building.Active = false
+				logf("Building ID=%d expired\n", building.ID)
+			}
+		}
+		if building.Active {
+			remaining = append(remaining, building)
+			continue
+		}
+		game.Grid.RemoveObstacle(building)
+		delete(game.BuildingMap, building.ID)
+	}
+	game.DeployedBuildings = remaining
+}
+
+// IsCrownTower reports whether the building is a king or princess tower
+// rather than one placed from a card. Towers have no template name.
+func (b *Building) IsCrownTower() bool {
+	return b.Name == ""
+}
+
+// teamBuildings lists every non-king building owned by a team, princess
+// towers first and then buildings placed from cards. Callers check Active.
+func teamBuildings(game *Game, team int) []*Building {
+	buildings := make([]*Building, 0, len(game.Teams[team].Buildings)+len(game.DeployedBuildings))
+	for i := range game.Teams[team].Buildings {
+		buildings = append(buildings, &game.Teams[team].Buildings[i])
+	}
+	for _, building := range game.DeployedBuildings {
+		if building.Team == team {
+			buildings = append(buildings, building)
+		}
+	}
+	return buildings
+}
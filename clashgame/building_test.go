@@ -0,0 +1,36 @@
+// building_test.go
+package clashgame
+
+import "testing"
+
+// TestDeployedBuildingWaitsToAttack places a Cannon next to an enemy Knight
+// and checks it only starts shooting once its DeployTime has passed
+func TestDeployedBuildingWaitsToAttack(t *testing.T) {
+	game, cmd := cannonGame(t)
+	applyCommand(game, cmd)
+	if len(game.DeployedBuildings) != 1 {
+		t.Fatal("the Cannon wasn't placed")
+	}
+	cannon := game.DeployedBuildings[0]
+	template, _ := game.Catalog.Building("Cannon")
+	deployTicks := SecondsToTicks(template.DeployTime)
+	if deployTicks == 0 || cannon.DeployTicks != deployTicks {
+		t.Fatalf("Cannon deploys for %d ticks, want its DeployTime of %d", cannon.DeployTicks, deployTicks)
+	}
+
+	pos := Position{X: cannon.Position.X, Y: cannon.Position.Y - 2*game.Grid.CellHeight}
+	spawnLanded(t, game, "Knight", pos, EnemyTeam(cannon.Team))
+
+	for tick := 1; tick <= deployTicks; tick++ {
+		game.GameTime++
+		UpdateDeployingTroops(game)
+		CheckBuildingCombat(game)
+		shooting := len(game.Projectiles) > 0
+		if tick < deployTicks && shooting {
+			t.Fatalf("Cannon fired on tick %d, before its %d deploy ticks", tick, deployTicks)
+		}
+		if tick == deployTicks && !shooting {
+			t.Fatalf("Cannon didn't fire once deployed on tick %d", tick)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// card.go
+package clashgame
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CardDefinition describes a deployable card. Troop cards summon Count units
+// of a troop template in a formation around the deploy point, building cards
+// place a building there and spell cards launch a projectile at it.
+type CardDefinition struct {
+	Name        string
+	Troop       string   // Troop template summoned (troop cards)
+	Building    string   // Building template placed (building cards)
+	Spell       string   // Projectile template launched (spell cards)
+	Count       int      // Units summoned per deploy
+	SpawnRadius float64  // Radius in grid cells of the default ring formation
+	Formation   []Vector // Explicit offsets in grid cells, one per unit (optional)
+	ElixirCost  int
+}
+
+// IsSpell reports whether the card casts a spell instead of summoning troops
+func (c *CardDefinition) IsSpell() bool {
+	return c.Spell != ""
+}
+
+// IsBuilding reports whether the card places a building
+func (c *CardDefinition) IsBuilding() bool {
+	return c.Building != ""
+}
+
+// defaultCardCost is used for single-unit cards without a known cost
+const defaultCardCost = 3
+
+// multiUnitCards are the built-in cards that summon more than one unit or
+// need an explicit formation
+var multiUnitCards = []CardDefinition{
+	{Name: "SkeletonArmy", Troop: "Skeleton", Count: 15, SpawnRadius: 1.5, ElixirCost: 3},
+	{Name: "Skeletons", Troop: "Skeleton", Count: 3, SpawnRadius: 0.7, ElixirCost: 1},
+	{Name: "Barbarians", Troop: "Barbarian", Count: 5, SpawnRadius: 1.0, ElixirCost: 5},
+	{Name: "MinionHorde", Troop: "Minion", Count: 6, SpawnRadius: 1.0, ElixirCost: 5},
+	{Name: "Minions", Troop: "Minion", Count: 3, SpawnRadius: 0.7, ElixirCost: 3},
+	{Name: "Goblins", Troop: "Goblin", Count: 4, SpawnRadius: 0.7, ElixirCost: 2},
+	{Name: "SpearGoblins", Troop: "SpearGoblin", Count: 3, SpawnRadius: 0.7, ElixirCost: 2},
+	{Name: "Bats", Troop: "Bat", Count: 5, SpawnRadius: 0.8, ElixirCost: 2},
+	{Name: "Archers", Troop: "Archer", Count: 2, Formation: []Vector{{X: -0.6}, {X: 0.6}}, ElixirCost: 3},
+	{
+		Name:  "ThreeMusketeers",
+		Troop: "ThreeMusketeer",
+		Count: 3,
+		// One in front, two behind, for the bottom player
+		Formation:  []Vector{{X: 0, Y: -0.8}, {X: -0.8, Y: 0.5}, {X: 0.8, Y: 0.5}},
+		ElixirCost: 9,
+	},
+}
+
+// spellCards are the built-in spells and the projectile each one launches
+var spellCards = []CardDefinition{
+	{Name: "Fireball", Spell: "FireballSpell", ElixirCost: 4},
+	{Name: "Arrows", Spell: "ArrowsSpell", ElixirCost: 3},
+	{Name: "Rocket", Spell: "RocketSpell", ElixirCost: 6},
+	{Name: "Lightning", Spell: "LighningSpell", ElixirCost: 6},
+	{Name: "Log", Spell: "LogProjectile", ElixirCost: 2},
+	{Name: "GiantSnowball", Spell: "SnowballSpell", ElixirCost: 2},
+}
+
+// buildingCards are the built-in building cards
+var buildingCards = []CardDefinition{
+	{Name: "Cannon", Building: "Cannon", ElixirCost: 3},
+	{Name: "Tesla", Building: "Tesla", ElixirCost: 4},
+	{Name: "InfernoTower", Building: "InfernoTower", ElixirCost: 5},
+	{Name: "BombTower", Building: "BombTower", ElixirCost: 4},
+	{Name: "Mortar", Building: "Mortar", ElixirCost: 4},
+}
+
+// singleUnitCosts holds elixir costs for troops deployed as their own card
+var singleUnitCosts = map[string]int{
+	"Knight":        3,
+	"Giant":         5,
+	"Pekka":         7,
+	"Balloon":       5,
+	"Witch":         5,
+	"Golem":         8,
+	"Valkyrie":      4,
+	"Bomber":        2,
+	"Musketeer":     4,
+	"BabyDragon":    4,
+	"MiniPekka":     4,
+	"Wizard":        5,
+	"Prince":        5,
+	"GiantSkeleton": 6,
+	"HogRider":      4,
+	"IceWizard":     3,
+	"RoyalGiant":    6,
+	"Princess":      3,
+	"DarkPrince":    4,
+	"LavaHound":     7,
+	"Miner":         3,
+	"Bowler":        5,
+	"MegaMinion":    3,
+	"InfernoDragon": 4,
+	"ElectroWizard": 4,
+	"MegaKnight":    7,
+	"Hunter":        4,
+	"IceSpirits":    1,
+	"FireSpirits":   1,
+	"ElectroSpirit": 1,
+	"Skeleton":      1,
+	"Archer":        2,
+	"Minion":        1,
+	"Goblin":        1,
+	"Bat":           1,
+}
+
+// buildCardDefinitions returns the card set for a catalog: the built-in
+// multi-unit, building and spell cards whose templates exist, plus a
+// single-unit card for every troop so any troop can still be deployed by name
+func buildCardDefinitions(troops map[string]*TroopTemplate, projectiles map[string]*ProjectileTemplate, buildings map[string]*BuildingTemplate) map[string]*CardDefinition {
+	cards := make(map[string]*CardDefinition)
+
+	for name := range troops {
+		cost, exists := singleUnitCosts[name]
+		if !exists {
+			cost = defaultCardCost
+		}
+		cards[name] = &CardDefinition{Name: name, Troop: name, Count: 1, ElixirCost: cost}
+	}
+
+	for i := range multiUnitCards {
+		card := multiUnitCards[i]
+		template, exists := troops[card.Troop]
+		if !exists {
+			continue
+		}
+		// Fall back to the template's own spawn radius for the formation
+		if card.SpawnRadius == 0 && len(card.Formation) == 0 {
+			card.SpawnRadius = template.SpawnRadius
+		}
+		cards[card.Name] = &card
+	}
+
+	for i := range buildingCards {
+		card := buildingCards[i]
+		if _, exists := buildings[card.Building]; exists {
+			cards[card.Name] = &card
+		}
+	}
+
+	for i := range spellCards {
+		card := spellCards[i]
+		if _, exists := projectiles[card.Spell]; exists {
+			cards[card.Name] = &card
+		}
+	}
+
+	return cards
+}
+
+// RingFormation spreads count units evenly on a circle. Larger groups also
+// get a unit in the middle so the blob stays filled like in the real game.
+func RingFormation(count int, radius float64) []Vector {
+	if count <= 1 || radius <= 0 {
+		return make([]Vector, count)
+	}
+
+	offsets := make([]Vector, 0, count)
+	ringCount := count
+	if count >= 6 {
+		offsets = append(offsets, Vector{})
+		ringCount--
+	}
+
+	for i := 0; i < ringCount; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(ringCount)
+		offsets = append(offsets, Vector{
+			X: radius * math.Cos(angle),
+			Y: radius * math.Sin(angle),
+		})
+	}
+	return offsets
+}
+
+// FormationOffsets returns the offset in grid cells of each unit, as seen by
+// the bottom player
+func (c *CardDefinition) FormationOffsets() []Vector {
+	if len(c.Formation) > 0 {
+		return c.Formation
+	}
+	return RingFormation(c.Count, c.SpawnRadius)
+}
+
+// DeployCard spawns every unit of a player's troop card around a cell, or
+// places a building card's building on it. Units whose formation slot isn't
+// deployable land on the center cell instead. All units share a group ID so
+// movement keeps them together.
+func DeployCard(g *Game, cardName string, col, row, player int) error {
+	card, exists := g.Catalog.Card(cardName)
+	if !exists {
+		return fmt.Errorf("card not found: %s", cardName)
+	}
+	if card.IsSpell() {
+		return fmt.Errorf("card %s is a spell", cardName)
+	}
+
+	team := g.Players[player].Team
+	level := g.Players[player].CardLevel(card.Name)
+	if card.IsBuilding() {
+		return PlaceBuilding(g, card.Building, col, row, player, level)
+	}
+
+	center := g.Grid.CellToPosition(col, row)
+
+	// Formations are authored for the bottom player; the top player
+	// (team 0) faces the other way
+	mirror := 1.0
+	if team == 0 {
+		mirror = -1.0
+	}
+
+	g.NextGroupID++
+	groupID := g.NextGroupID
+
+	for _, offset := range card.FormationOffsets() {
+		pos := Position{
+			X: center.X + offset.X*g.Grid.CellWidth,
+			Y: center.Y + offset.Y*mirror*g.Grid.CellHeight,
+		}
+		offsetCol, offsetRow := g.Grid.PositionToCell(pos)
+		if validateTargetCell(g, offsetCol, offsetRow) != nil {
+			pos = center
+		}
+
+		troop, err := NewExtendedTroop(g.Catalog, pos.X, pos.Y, card.Troop, team, level, g.Grid)
+		if err != nil {
+			return err
+		}
+		troop.GroupID = groupID
+		troop.Card = card.Name
+		troop.Owner = player
+		SpawnTroop(troop.Troop, team, g)
+	}
+
+	return nil
+}
+
+// CardNames returns all card names in sorted order
+func (c *Catalog) CardNames() []string {
+	names := make([]string, 0, len(c.cards))
+	for name := range c.cards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Card looks up a card definition by name
+func (c *Catalog) Card(name string) (*CardDefinition, bool) {
+	card, exists := c.cards[name]
+	return card, exists
+}
+
+// CardTroop returns the troop template a card summons, if it is a troop card
+func (c *Catalog) CardTroop(name string) (*TroopTemplate, bool) {
+	card, exists := c.cards[name]
+	if !exists || card.Troop == "" {
+		return nil, false
+	}
+	return c.Troop(card.Troop)
+}
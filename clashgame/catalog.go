@@ -0,0 +1,147 @@
+// catalog.go
+package clashgame
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// Catalog is an immutable set of troop, projectile and building templates and
+// the cards built from them. Load it once
+// per balance patch and hand it to every Game that should use that data.
+// Games only ever read from it, so several catalogs can be used side by side
+// and one catalog can be shared across many concurrent matches. Templates
+// returned by the lookup methods must be treated as read-only.
+type Catalog struct {
+	troops      map[string]*TroopTemplate
+	projectiles map[string]*ProjectileTemplate
+	buildings   map[string]*BuildingTemplate
+	cards       map[string]*CardDefinition
+}
+
+// NewCatalog builds a catalog from template maps. The maps and templates are
+// copied, so later changes by the caller don't leak into the catalog.
+func NewCatalog(troops map[string]*TroopTemplate, projectiles map[string]*ProjectileTemplate, buildings map[string]*BuildingTemplate) *Catalog {
+	catalog := &Catalog{
+		troops:      make(map[string]*TroopTemplate, len(troops)),
+		projectiles: make(map[string]*ProjectileTemplate, len(projectiles)),
+		buildings:   make(map[string]*BuildingTemplate, len(buildings)),
+	}
+
+	for name, template := range troops {
+		copied := *template
+		catalog.troops[name] = &copied
+	}
+	for name, template := range projectiles {
+		copied := *template
+		catalog.projectiles[name] = &copied
+	}
+	for name, template := range buildings {
+		copied := *template
+		catalog.buildings[name] = &copied
+	}
+	catalog.cards = buildCardDefinitions(catalog.troops, catalog.projectiles, catalog.buildings)
+
+	return catalog
+}
+
+// LoadCatalog loads troop, projectile and building templates from the CSVs
+// in a data FS (see OpenData). The built-in projectiles (such as the
+// "normal" building shot) are always present; CSV rows with the same name
+// override them. A projectile CSV that can't be read only costs the CSV
+// projectiles, like the troop fallback, and a building CSV that can't be
+// read falls back to the built-in buildings.
+func LoadCatalog(fsys fs.FS) (*Catalog, error) {
+	return loadCatalog(fsys, false)
+}
+
+// ReloadCatalog loads the template CSVs like LoadCatalog but fails when any
+// of them can't be read instead of falling back, so a half-saved or broken
+// edit never replaces data that works
+func ReloadCatalog(fsys fs.FS) (*Catalog, error) {
+	return loadCatalog(fsys, true)
+}
+
+func loadCatalog(fsys fs.FS, strict bool) (*Catalog, error) {
+	projectiles := defaultProjectileTemplates()
+
+	loaded, err := LoadProjectileTemplates(fsys, ProjectilesCSVPath)
+	if err != nil {
+		if strict {
+			return nil, fmt.Errorf("loading projectiles: %v", err)
+		}
+		logf("Warning: Failed to load projectile templates from CSV: %v\n", err)
+	}
+	for name, template := range loaded {
+		projectiles[name] = template
+	}
+
+	load := LoadTroopTemplates
+	if strict {
+		load = loadTroopTemplatesFromCSV
+	}
+	troops, err := load(fsys, TroopsCSVPath, projectiles)
+	if err != nil {
+		return nil, fmt.Errorf("loading troops: %v", err)
+	}
+
+	buildings, err := LoadBuildingTemplates(fsys, BuildingsCSVPath)
+	if err != nil {
+		if strict {
+			return nil, fmt.Errorf("loading buildings: %v", err)
+		}
+		logf("Warning: Failed to load building templates from CSV: %v\n", err)
+		buildings = defaultBuildingTemplates()
+	}
+
+	return NewCatalog(troops, projectiles, buildings), nil
+}
+
+// DefaultCatalog returns a catalog made of the built-in fallback templates
+func DefaultCatalog() *Catalog {
+	return NewCatalog(defaultTroopTemplates(), defaultProjectileTemplates(), defaultBuildingTemplates())
+}
+
+// Troop looks up a troop template by name
+func (c *Catalog) Troop(name string) (*TroopTemplate, bool) {
+	template, exists := c.troops[name]
+	return template, exists
+}
+
+// Projectile looks up a projectile template by name
+func (c *Catalog) Projectile(name string) (*ProjectileTemplate, bool) {
+	template, exists := c.projectiles[name]
+	return template, exists
+}
+
+// Building looks up a building template by name
+func (c *Catalog) Building(name string) (*BuildingTemplate, bool) {
+	template, exists := c.buildings[name]
+	return template, exists
+}
+
+// TroopNames returns all troop template names in sorted order
+func (c *Catalog) TroopNames() []string {
+	names := make([]string, 0, len(c.troops))
+	for name := range c.troops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProjectileNames returns all projectile template names in sorted order
+func (c *Catalog) ProjectileNames() []string {
+	names := make([]string, 0, len(c.projectiles))
+	for name := range c.projectiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NumTroops returns how many troop templates the catalog holds
+func (c *Catalog) NumTroops() int {
+	return len(c.troops)
+}
@@ -0,0 +1,46 @@
+// catalog_test.go
+package clashgame
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+var (
+	embeddedCatalogOnce sync.Once
+	embeddedCatalog     *Catalog
+	embeddedCatalogErr  error
+)
+
+// testCatalog returns the catalog of the embedded game data, loaded once.
+// Tests use it rather than DefaultCatalog so they play with the shipped
+// CSV values.
+func testCatalog(t testing.TB) *Catalog {
+	t.Helper()
+	embeddedCatalogOnce.Do(func() {
+		embeddedCatalog, embeddedCatalogErr = LoadCatalog(DefaultData())
+	})
+	if embeddedCatalogErr != nil {
+		t.Fatalf("loading the embedded catalog: %v", embeddedCatalogErr)
+	}
+	return embeddedCatalog
+}
+
+// TestLogOutput loads a catalog from data without CSVs: the warnings go to
+// LogOutput, not stdout
+func TestLogOutput(t *testing.T) {
+	var buf bytes.Buffer
+	LogOutput = &buf
+	defer func() { LogOutput = os.Stdout }()
+
+	if _, err := LoadCatalog(fstest.MapFS{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Warning") {
+		t.Errorf("no warnings were logged, got %q", buf.String())
+	}
+}
@@ -0,0 +1,417 @@
+// codec.go
+package clashgame
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SnapshotCodec turns snapshots and deltas into messages for clients
+type SnapshotCodec interface {
+	EncodeSnapshot(snapshot *Snapshot) ([]byte, error)
+	DecodeSnapshot(data []byte) (*Snapshot, error)
+	EncodeDelta(delta *SnapshotDelta) ([]byte, error)
+	DecodeDelta(data []byte) (*SnapshotDelta, error)
+}
+
+// snapshotFormatVersion is the first byte of every binary message. Bump it
+// whenever the layout below changes.
+const snapshotFormatVersion = 2
+
+// BinaryCodec is the compact wire format for streaming a match at 30 Hz.
+// Numbers are varints, positions are sent in the 1/16 pixel steps snapshots
+// are rounded to, troop and building IDs as gaps to the previous ID, and
+// only the flagged fields of a change. A snapshot is sent as a keyframe
+// delta. Decoding never trusts the input: malformed messages are errors,
+// never panics or huge allocations.
+//
+// Layout of a delta:
+//
+//	version byte, tick, base tick + 1, phase byte, winner
+//	players: count, then elixir in 1/100 steps and the hand (count, then
+//	card names) each
+//	troops: count, then ID gap, fields byte and the flagged fields each
+//	removed troops: count, then ID gaps
+//	buildings and removed buildings, like troops without the status
+//	projectiles: count, then template, team byte, x and y each
+type BinaryCodec struct{}
+
+// JSONCodec encodes snapshots and deltas as readable JSON, for debugging
+// and tools
+type JSONCodec struct{}
+
+// EncodeSnapshot encodes a snapshot as a keyframe
+func (BinaryCodec) EncodeSnapshot(snapshot *Snapshot) ([]byte, error) {
+	return BinaryCodec{}.EncodeDelta(Diff(nil, snapshot))
+}
+
+// DecodeSnapshot decodes a keyframe written by EncodeSnapshot
+func (BinaryCodec) DecodeSnapshot(data []byte) (*Snapshot, error) {
+	delta, err := BinaryCodec{}.DecodeDelta(data)
+	if err != nil {
+		return nil, err
+	}
+	if delta.BaseTick != NoBaseTick {
+		return nil, fmt.Errorf("message is a delta against tick %d, not a snapshot", delta.BaseTick)
+	}
+	return delta.Apply(nil)
+}
+
+// EncodeDelta encodes a delta. Troops, buildings and removed IDs must be
+// sorted by ID, as Diff leaves them.
+func (BinaryCodec) EncodeDelta(delta *SnapshotDelta) ([]byte, error) {
+	if delta.BaseTick < NoBaseTick || delta.Tick < 0 {
+		return nil, fmt.Errorf("invalid ticks %d/%d", delta.BaseTick, delta.Tick)
+	}
+
+	buf := []byte{snapshotFormatVersion}
+	buf = binary.AppendUvarint(buf, uint64(delta.Tick))
+	buf = binary.AppendUvarint(buf, uint64(delta.BaseTick+1))
+	buf = append(buf, byte(delta.Phase))
+	buf = binary.AppendVarint(buf, int64(delta.Winner))
+
+	buf = binary.AppendUvarint(buf, uint64(len(delta.Players)))
+	for _, player := range delta.Players {
+		buf = binary.AppendUvarint(buf, uint64(math.Round(math.Max(player.Elixir, 0)*snapshotElixirScale)))
+		buf = binary.AppendUvarint(buf, uint64(len(player.Hand)))
+		for _, card := range player.Hand {
+			buf = appendString(buf, card)
+		}
+	}
+
+	var err error
+	buf = binary.AppendUvarint(buf, uint64(len(delta.Troops)))
+	previous := 0
+	for _, change := range delta.Troops {
+		if buf, err = appendIDGap(buf, &previous, change.State.ID); err != nil {
+			return nil, err
+		}
+		troop := change.State
+		buf = appendEntityFields(buf, change.Fields, troop.Template, troop.Team, troop.X, troop.Y, troop.Health, troop.MaxHealth)
+		if change.Fields&FieldStatus != 0 {
+			buf = append(buf, byte(troop.Status))
+		}
+	}
+	if buf, err = appendIDs(buf, delta.RemovedTroops); err != nil {
+		return nil, err
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(delta.Buildings)))
+	previous = 0
+	for _, change := range delta.Buildings {
+		if buf, err = appendIDGap(buf, &previous, change.State.ID); err != nil {
+			return nil, err
+		}
+		building := change.State
+		buf = appendEntityFields(buf, change.Fields&^FieldStatus, building.Template, building.Team, building.X, building.Y, building.Health, building.MaxHealth)
+	}
+	if buf, err = appendIDs(buf, delta.RemovedBuildings); err != nil {
+		return nil, err
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(delta.Projectiles)))
+	for _, projectile := range delta.Projectiles {
+		buf = appendString(buf, projectile.Template)
+		buf = append(buf, byte(projectile.Team))
+		buf = binary.AppendVarint(buf, positionSteps(projectile.X))
+		buf = binary.AppendVarint(buf, positionSteps(projectile.Y))
+	}
+	return buf, nil
+}
+
+// appendEntityFields writes the flagged fields shared by troops and
+// buildings
+func appendEntityFields(buf []byte, fields DeltaFields, template string, team int, x, y float64, health, maxHealth int) []byte {
+	buf = append(buf, byte(fields))
+	if fields&FieldIdentity != 0 {
+		buf = appendString(buf, template)
+		buf = append(buf, byte(team))
+	}
+	if fields&FieldPosition != 0 {
+		buf = binary.AppendVarint(buf, positionSteps(x))
+		buf = binary.AppendVarint(buf, positionSteps(y))
+	}
+	if fields&FieldHealth != 0 {
+		buf = binary.AppendVarint(buf, int64(health))
+	}
+	if fields&FieldMaxHealth != 0 {
+		buf = binary.AppendVarint(buf, int64(maxHealth))
+	}
+	return buf
+}
+
+// appendIDGap writes an ID as the gap to the previous one
+func appendIDGap(buf []byte, previous *int, id int) ([]byte, error) {
+	if id < *previous {
+		return nil, fmt.Errorf("IDs must be sorted, got %d after %d", id, *previous)
+	}
+	buf = binary.AppendUvarint(buf, uint64(id-*previous))
+	*previous = id
+	return buf, nil
+}
+
+// appendIDs writes a sorted list of IDs
+func appendIDs(buf []byte, ids []int) ([]byte, error) {
+	buf = binary.AppendUvarint(buf, uint64(len(ids)))
+	previous := 0
+	var err error
+	for _, id := range ids {
+		if buf, err = appendIDGap(buf, &previous, id); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// appendString writes a length-prefixed string
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// positionSteps converts a coordinate to 1/16 pixel steps
+func positionSteps(value float64) int64 {
+	return int64(math.Round(value * snapshotPositionScale))
+}
+
+// DecodeDelta decodes a delta written by EncodeDelta
+func (BinaryCodec) DecodeDelta(data []byte) (*SnapshotDelta, error) {
+	r := &snapshotReader{data: data}
+	if version := r.byte(); r.err == nil && version != snapshotFormatVersion {
+		return nil, fmt.Errorf("unsupported snapshot format version %d", version)
+	}
+
+	delta := &SnapshotDelta{
+		Tick:     r.int(),
+		BaseTick: r.int() - 1,
+		Phase:    MatchPhase(r.byte()),
+		Winner:   int(r.varint()),
+	}
+	if delta.Tick < 0 || delta.Winner < NoWinner || delta.Winner > math.MaxInt32 {
+		r.fail("invalid header")
+	}
+
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		player := PlayerState{Elixir: float64(r.int()) / snapshotElixirScale}
+		for cards := r.count(); cards > 0 && r.err == nil; cards-- {
+			player.Hand = append(player.Hand, r.string())
+		}
+		delta.Players = append(delta.Players, player)
+	}
+
+	previous := 0
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		change := TroopDelta{State: TroopState{ID: r.nextID(&previous)}}
+		change.Fields = DeltaFields(r.byte())
+		state := &change.State
+		r.entityFields(change.Fields, &state.Template, &state.Team, &state.X, &state.Y, &state.Health, &state.MaxHealth)
+		if change.Fields&FieldStatus != 0 {
+			state.Status = TroopStatus(r.byte())
+		}
+		delta.Troops = append(delta.Troops, change)
+	}
+	delta.RemovedTroops = r.ids()
+
+	previous = 0
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		change := BuildingDelta{State: BuildingState{ID: r.nextID(&previous)}}
+		change.Fields = DeltaFields(r.byte())
+		if change.Fields&FieldStatus != 0 {
+			r.fail("buildings have no status")
+		}
+		state := &change.State
+		r.entityFields(change.Fields, &state.Template, &state.Team, &state.X, &state.Y, &state.Health, &state.MaxHealth)
+		delta.Buildings = append(delta.Buildings, change)
+	}
+	delta.RemovedBuildings = r.ids()
+
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		delta.Projectiles = append(delta.Projectiles, ProjectileState{
+			Template: r.string(),
+			Team:     int(r.byte()),
+			X:        r.position(),
+			Y:        r.position(),
+		})
+	}
+
+	if r.err == nil && len(r.data) > 0 {
+		r.fail(fmt.Sprintf("%d trailing bytes", len(r.data)))
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return delta, nil
+}
+
+// snapshotReader reads a binary message. The first problem sticks: later
+// reads return zero values, so decoding stops at the next loop check.
+type snapshotReader struct {
+	data []byte
+	err  error
+}
+
+// fail records a decoding problem
+func (r *snapshotReader) fail(problem string) {
+	if r.err == nil {
+		r.err = errors.New("malformed snapshot message: " + problem)
+	}
+}
+
+func (r *snapshotReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) == 0 {
+		r.fail("truncated")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *snapshotReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail("bad varint")
+		return 0
+	}
+	r.data = r.data[n:]
+	return value
+}
+
+func (r *snapshotReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	value, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail("bad varint")
+		return 0
+	}
+	r.data = r.data[n:]
+	return value
+}
+
+// int reads an unsigned varint that must fit an int32
+func (r *snapshotReader) int() int {
+	value := r.uvarint()
+	if value > math.MaxInt32 {
+		r.fail("number out of range")
+		return 0
+	}
+	return int(value)
+}
+
+// signed reads a varint that must fit an int32
+func (r *snapshotReader) signed() int {
+	value := r.varint()
+	if value < math.MinInt32 || value > math.MaxInt32 {
+		r.fail("number out of range")
+		return 0
+	}
+	return int(value)
+}
+
+// count reads a list length. Every element takes at least a byte, so a
+// length beyond the rest of the message is an error rather than an
+// allocation.
+func (r *snapshotReader) count() int {
+	n := r.int()
+	if n > len(r.data) {
+		r.fail("list longer than the message")
+		return 0
+	}
+	return n
+}
+
+func (r *snapshotReader) string() string {
+	n := r.count()
+	if r.err != nil {
+		return ""
+	}
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+	return s
+}
+
+func (r *snapshotReader) position() float64 {
+	return float64(r.signed()) / snapshotPositionScale
+}
+
+// nextID reads an ID written as the gap to the previous one
+func (r *snapshotReader) nextID(previous *int) int {
+	id := *previous + r.int()
+	if id > math.MaxInt32 {
+		r.fail("ID out of range")
+		return 0
+	}
+	*previous = id
+	return id
+}
+
+// ids reads a list written by appendIDs
+func (r *snapshotReader) ids() []int {
+	var ids []int
+	previous := 0
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		ids = append(ids, r.nextID(&previous))
+	}
+	return ids
+}
+
+// entityFields reads the flagged fields written by appendEntityFields
+func (r *snapshotReader) entityFields(fields DeltaFields, template *string, team *int, x, y *float64, health, maxHealth *int) {
+	if fields&^troopFields != 0 {
+		r.fail("unknown field flags")
+		return
+	}
+	if fields&FieldIdentity != 0 {
+		*template = r.string()
+		*team = int(r.byte())
+	}
+	if fields&FieldPosition != 0 {
+		*x = r.position()
+		*y = r.position()
+	}
+	if fields&FieldHealth != 0 {
+		*health = r.signed()
+	}
+	if fields&FieldMaxHealth != 0 {
+		*maxHealth = r.signed()
+	}
+}
+
+// EncodeSnapshot encodes a snapshot as JSON
+func (JSONCodec) EncodeSnapshot(snapshot *Snapshot) ([]byte, error) {
+	return json.Marshal(snapshot)
+}
+
+// DecodeSnapshot decodes a snapshot written by EncodeSnapshot
+func (JSONCodec) DecodeSnapshot(data []byte) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// EncodeDelta encodes a delta as JSON
+func (JSONCodec) EncodeDelta(delta *SnapshotDelta) ([]byte, error) {
+	return json.Marshal(delta)
+}
+
+// DecodeDelta decodes a delta written by EncodeDelta
+func (JSONCodec) DecodeDelta(data []byte) (*SnapshotDelta, error) {
+	var delta SnapshotDelta
+	if err := json.Unmarshal(data, &delta); err != nil {
+		return nil, err
+	}
+	return &delta, nil
+}
@@ -0,0 +1,154 @@
+// codec_test.go
+package clashgame
+
+import (
+	"reflect"
+	"testing"
+)
+
+// matchSnapshots plays a bot match and returns a snapshot of every tick
+func matchSnapshots(t testing.TB, ticks int) []*Snapshot {
+	t.Helper()
+	config := DefaultGameConfig()
+	config.Seed = 7
+	game := NewGame(testCatalog(t), nil, config)
+	for i := range game.Players {
+		game.AddBot(NewBot(i, BotHard, int64(i+1)))
+	}
+	game.Running = true
+
+	var snapshots []*Snapshot
+	for game.GameTime < ticks && game.Phase != PhaseEnded {
+		game.Tick()
+		snapshots = append(snapshots, game.Snapshot())
+	}
+	return snapshots
+}
+
+// TestSnapshotRoundTrip streams a match to a client that loses every fifth
+// delta and acknowledges most of the rest. Every delta it receives must go
+// through the codec and rebuild the exact snapshot the server took.
+func TestSnapshotRoundTrip(t *testing.T) {
+	snapshots := matchSnapshots(t, 1500)
+	codecs := map[string]SnapshotCodec{"binary": BinaryCodec{}, "json": JSONCodec{}}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			stream := NewSnapshotStream(TicksPerSecond)
+			received := make(map[int]*Snapshot)
+			troopsSeen := 0
+			for i, snapshot := range snapshots {
+				data, err := codec.EncodeDelta(stream.Next(snapshot))
+				if err != nil {
+					t.Fatalf("tick %d: encoding: %v", snapshot.Tick, err)
+				}
+				if i%5 == 4 {
+					continue // Lost on the way
+				}
+
+				delta, err := codec.DecodeDelta(data)
+				if err != nil {
+					t.Fatalf("tick %d: decoding: %v", snapshot.Tick, err)
+				}
+				got, err := delta.Apply(received[delta.BaseTick])
+				if err != nil {
+					t.Fatalf("tick %d: applying: %v", snapshot.Tick, err)
+				}
+				if !reflect.DeepEqual(got, snapshot) {
+					t.Fatalf("tick %d: got\n%+v\nwant\n%+v", snapshot.Tick, got, snapshot)
+				}
+				received[snapshot.Tick] = got
+				troopsSeen += len(got.Troops)
+				if i%7 != 0 {
+					stream.Ack(snapshot.Tick)
+				}
+			}
+			if troopsSeen == 0 {
+				t.Error("the match never had troops on the field")
+			}
+		})
+	}
+}
+
+// TestSnapshotCodecKeyframe checks that both codecs return a full snapshot
+// unchanged
+func TestSnapshotCodecKeyframe(t *testing.T) {
+	snapshots := matchSnapshots(t, 600)
+	snapshot := snapshots[len(snapshots)-1]
+	for name, codec := range map[string]SnapshotCodec{"binary": BinaryCodec{}, "json": JSONCodec{}} {
+		data, err := codec.EncodeSnapshot(snapshot)
+		if err != nil {
+			t.Fatalf("%s: encoding: %v", name, err)
+		}
+		got, err := codec.DecodeSnapshot(data)
+		if err != nil {
+			t.Fatalf("%s: decoding: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, snapshot) {
+			t.Errorf("%s: got\n%+v\nwant\n%+v", name, got, snapshot)
+		}
+	}
+}
+
+// TestApplyRejectsWrongBase checks that a delta is only applied to the
+// snapshot it was made against
+func TestApplyRejectsWrongBase(t *testing.T) {
+	snapshots := matchSnapshots(t, 300)
+	base, next := snapshots[len(snapshots)-3], snapshots[len(snapshots)-1]
+	delta := Diff(base, next)
+	if _, err := delta.Apply(snapshots[len(snapshots)-2]); err == nil {
+		t.Error("delta applied to a snapshot of the wrong tick")
+	}
+	if _, err := delta.Apply(nil); err == nil {
+		t.Error("delta applied without a base")
+	}
+}
+
+// FuzzBinaryCodecDecodeDelta feeds arbitrary bytes to the binary decoder. It
+// must never panic, every list it returns must fit in the message, and
+// whatever it accepts must encode back to a message that decodes the same.
+func FuzzBinaryCodecDecodeDelta(f *testing.F) {
+	snapshots := matchSnapshots(f, 900)
+	var codec BinaryCodec
+	for i := 0; i < len(snapshots); i += 100 {
+		var base *Snapshot
+		if i > 0 {
+			base = snapshots[i-1]
+		}
+		data, err := codec.EncodeDelta(Diff(base, snapshots[i]))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte{})
+	f.Add([]byte{snapshotFormatVersion})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		delta, err := codec.DecodeDelta(data)
+		if err != nil {
+			return
+		}
+
+		// Every element takes at least a byte, so a short message can't
+		// make the decoder allocate large lists
+		elements := len(delta.Players) + len(delta.Troops) + len(delta.RemovedTroops) +
+			len(delta.Buildings) + len(delta.RemovedBuildings) + len(delta.Projectiles)
+		if elements > len(data) {
+			t.Fatalf("%d bytes decoded to %d elements", len(data), elements)
+		}
+
+		encoded, err := codec.EncodeDelta(delta)
+		if err != nil {
+			t.Fatalf("re-encoding a decoded delta: %v", err)
+		}
+		again, err := codec.DecodeDelta(encoded)
+		if err != nil {
+			t.Fatalf("decoding a re-encoded delta: %v", err)
+		}
+		if !reflect.DeepEqual(again, delta) {
+			t.Fatalf("re-encoded delta changed:\n%+v\n%+v", delta, again)
+		}
+		delta.Apply(nil)
+	})
+}
@@ -0,0 +1,169 @@
+// collision.go
+package clashgame
+
+import "math"
+
+// DefaultTroopMass is used for troops whose template has no Mass
+const DefaultTroopMass = 4.0
+
+// KnockbackReferenceMass is the heaviest mass that still takes the full
+// knockback distance; heavier units are moved proportionally less
+const KnockbackReferenceMass = 4.0
+
+// TroopMass returns the mass a troop collides with
+func TroopMass(troop *Troop) float64 {
+	if troop.Mass > 0 {
+		return troop.Mass
+	}
+	return DefaultTroopMass
+}
+
+// IgnoresPushback checks if a troop is immune to knockback
+func IgnoresPushback(troop *Troop) bool {
+	template := GetTroopTemplate(troop)
+	return template != nil && template.IgnorePushback
+}
+
+// ResolveCollisions separates overlapping units once per tick. Two units
+// share the overlap in inverse proportion to their mass, so a Golem walks
+// through a crowd of Skeletons while they get shoved aside. Deploying troops
+// and buildings never move; ground troops are pushed out of building
+// footprints instead. Flying and ground units don't collide with each other.
+func ResolveCollisions(game *Game) {
+	for i := range game.Troops {
+		a := &game.Troops[i]
+		if !a.Active {
+			continue
+		}
+		aFlying := IsFlyingTroop(a)
+
+		for j := i + 1; j < len(game.Troops); j++ {
+			b := &game.Troops[j]
+			if !b.Active || IsFlyingTroop(b) != aFlying {
+				continue
+			}
+			separateTroops(a, b)
+		}
+	}
+
+	for i := range game.Troops {
+		troop := &game.Troops[i]
+		if !troop.Active || IsFlyingTroop(troop) {
+			continue
+		}
+		for team := range game.Teams {
+			buildings := append(teamBuildings(game, team), &game.Teams[team].KingBuilding.Building)
+			for _, building := range buildings {
+				if building.Active {
+					pushOutOfBuilding(troop, building, game.Grid)
+				}
+			}
+		}
+	}
+}
+
+// separateTroops pushes two overlapping troops apart by mass
+func separateTroops(a, b *Troop) {
+	dx := b.Position.X - a.Position.X
+	dy := b.Position.Y - a.Position.Y
+	dist := math.Sqrt(dx*dx + dy*dy)
+	overlap := a.Size/2 + b.Size/2 - dist
+	if overlap <= 0 {
+		return
+	}
+
+	// Units stacked exactly on top of each other split along the x axis,
+	// lower ID to the left, so the result doesn't depend on float noise
+	if dist == 0 {
+		dx, dy, dist = 1, 0, 1
+		if a.ID > b.ID {
+			dx = -1
+		}
+	}
+	nx, ny := dx/dist, dy/dist
+
+	// Deploying troops hold their ground like buildings
+	massA, massB := TroopMass(a), TroopMass(b)
+	var shareA, shareB float64
+	switch {
+	case a.IsDeploying() && b.IsDeploying():
+		return
+	case a.IsDeploying():
+		shareB = 1
+	case b.IsDeploying():
+		shareA = 1
+	default:
+		shareA = massB / (massA + massB)
+		shareB = massA / (massA + massB)
+	}
+
+	a.Position.X -= nx * overlap * shareA
+	a.Position.Y -= ny * overlap * shareA
+	b.Position.X += nx * overlap * shareB
+	b.Position.Y += ny * overlap * shareB
+}
+
+// pushOutOfBuilding moves a troop that overlaps a building's footprint to
+// the nearest point just outside it
+func pushOutOfBuilding(troop *Troop, building *Building, grid *GridSystem) {
+	width, height := building.GetPixelDimensions(grid)
+	left, right := building.Position.X-width/2, building.Position.X+width/2
+	top, bottom := building.Position.Y-height/2, building.Position.Y+height/2
+	radius := troop.Size / 2
+
+	// Closest point of the footprint rectangle to the troop
+	closestX := math.Max(left, math.Min(troop.Position.X, right))
+	closestY := math.Max(top, math.Min(troop.Position.Y, bottom))
+	dx := troop.Position.X - closestX
+	dy := troop.Position.Y - closestY
+	dist := math.Sqrt(dx*dx + dy*dy)
+
+	if dist >= radius {
+		return
+	}
+
+	if dist > 0 {
+		// Center outside the footprint: slide out along the contact normal
+		troop.Position.X += dx / dist * (radius - dist)
+		troop.Position.Y += dy / dist * (radius - dist)
+		return
+	}
+
+	// Center inside the footprint: leave through the closest edge
+	exits := []struct{ dist, x, y float64 }{
+		{troop.Position.X - left, left - radius, troop.Position.Y},
+		{right - troop.Position.X, right + radius, troop.Position.Y},
+		{troop.Position.Y - top, troop.Position.X, top - radius},
+		{bottom - troop.Position.Y, troop.Position.X, bottom + radius},
+	}
+	best := exits[0]
+	for _, exit := range exits[1:] {
+		if exit.dist < best.dist {
+			best = exit
+		}
+	}
+	troop.Position.X, troop.Position.Y = best.x, best.y
+}
+
+// ApplyKnockback pushes a troop along a direction by distance grid cells,
+// scaled down for units heavier than KnockbackReferenceMass. Troops with
+// IgnorePushback and deploying troops don't move.
+func ApplyKnockback(troop *Troop, dirX, dirY, distance float64, grid *GridSystem) {
+	if distance <= 0 || IgnoresPushback(troop) || troop.IsDeploying() {
+		return
+	}
+
+	length := math.Sqrt(dirX*dirX + dirY*dirY)
+	if length == 0 {
+		return
+	}
+
+	scale := math.Min(1, KnockbackReferenceMass/TroopMass(troop))
+	pixels := distance * grid.CellWidth * scale
+	troop.Position.X += dirX / length * pixels
+	troop.Position.Y += dirY / length * pixels
+
+	// Knocked back troops stop and replan
+	troop.Velocity = Position{X: 0, Y: 0}
+	troop.Path = nil
+}
@@ -0,0 +1,88 @@
+// collision_test.go
+package clashgame
+
+import (
+	"math"
+	"testing"
+)
+
+// spawnLanded spawns a troop from the default catalog that has finished
+// deploying and returns its index in game.Troops
+func spawnLanded(t *testing.T, game *Game, name string, pos Position, team int) int {
+	t.Helper()
+	troop, err := NewExtendedTroop(game.Catalog, pos.X, pos.Y, name, team, TournamentLevelCap, game.Grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	troop.DeployTicks = 0
+	SpawnTroop(troop.Troop, team, game)
+	return len(game.Troops) - 1
+}
+
+// TestGolemPushesSkeletons checks that overlapping units share the overlap
+// by mass: the skeletons crowding a Golem are shoved aside further than the
+// Golem moves
+func TestGolemPushesSkeletons(t *testing.T) {
+	game := NewGame(testCatalog(t), nil, DefaultGameConfig())
+	center := game.Grid.CellToPosition(game.Grid.Columns/2, game.Grid.Rows/2+6)
+
+	golem := spawnLanded(t, game, "Golem", center, 1)
+	var skeletons []int
+	for i := 0; i < 4; i++ {
+		angle := float64(i) * math.Pi / 2
+		pos := Position{X: center.X + 3*math.Cos(angle), Y: center.Y + 3*math.Sin(angle)}
+		skeletons = append(skeletons, spawnLanded(t, game, "Skeleton", pos, 0))
+	}
+
+	start := make(map[int]Position)
+	for i := range game.Troops {
+		start[i] = game.Troops[i].Position
+	}
+	ResolveCollisions(game)
+
+	golemMoved := Distance(start[golem], game.Troops[golem].Position)
+	for _, i := range skeletons {
+		moved := Distance(start[i], game.Troops[i].Position)
+		if moved <= golemMoved {
+			t.Errorf("skeleton %d moved %.2f px, not more than the Golem's %.2f px", i, moved, golemMoved)
+		}
+	}
+
+	// One on one the lighter unit takes the mass share of the overlap
+	game = NewGame(testCatalog(t), nil, DefaultGameConfig())
+	golem = spawnLanded(t, game, "Golem", center, 1)
+	skeleton := spawnLanded(t, game, "Skeleton", Position{X: center.X + 3, Y: center.Y}, 0)
+	ResolveCollisions(game)
+	golemMoved = Distance(center, game.Troops[golem].Position)
+	skeletonMoved := Distance(Position{X: center.X + 3, Y: center.Y}, game.Troops[skeleton].Position)
+	massRatio := TroopMass(&game.Troops[golem]) / TroopMass(&game.Troops[skeleton])
+	if math.Abs(skeletonMoved/golemMoved-massRatio) > 1e-6 {
+		t.Errorf("skeleton moved %.3f px and Golem %.3f px, want the mass ratio %g", skeletonMoved, golemMoved, massRatio)
+	}
+}
+
+// TestKnockback checks that knockback is scaled by mass and skips units
+// with IgnorePushback
+func TestKnockback(t *testing.T) {
+	game := NewGame(testCatalog(t), nil, DefaultGameConfig())
+	center := game.Grid.CellToPosition(game.Grid.Columns/2, game.Grid.Rows/2+6)
+
+	tests := []struct {
+		name string
+		want float64 // Cells moved by a knockback of 1 cell
+	}{
+		{"Skeleton", 1},
+		{"Knight", KnockbackReferenceMass / 6},
+		{"Golem", 0}, // IgnorePushback
+		{"Giant", 0}, // IgnorePushback
+	}
+	for _, tt := range tests {
+		i := spawnLanded(t, game, tt.name, center, 0)
+		troop := &game.Troops[i]
+		ApplyKnockback(troop, 0, 1, 1, game.Grid)
+		moved := Distance(center, troop.Position) / game.Grid.CellWidth
+		if math.Abs(moved-tt.want) > 1e-9 {
+			t.Errorf("%s knocked back %.3f cells, want %.3f", tt.name, moved, tt.want)
+		}
+	}
+}
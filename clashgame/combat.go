@@ -1,10 +1,8 @@
 package clashgame
 
-import (
-	"fmt"
-	"math"
-)
+import "math"
 
+// ProcessCombat lets two troops attack each other if they are able to
 func ProcessCombat(game *Game, troop1, troop2 *Troop) {
     // Recompute attack capabilities inside the function
     canAttack1to2 := CanAttackTroop(troop1, troop2, game.Grid)
@@ -14,152 +12,125 @@ func ProcessCombat(game *Game, troop1, troop2 *Troop) {
     troop1.IsAttacking = canAttack1to2
     troop2.IsAttacking = canAttack2to1
     
+    // Process each attack ONLY if that troop can attack the other
+    if canAttack1to2 {
+        ProcessTroopAttack(game, troop1, troop2)
+    }
+    if canAttack2to1 {
+        ProcessTroopAttack(game, troop2, troop1)
+    }
+}
+
+// ProcessTroopAttack makes attacker hit target once its attack is ready.
+// The first hit on a newly acquired target uses DamageSpecial when the
+// template has one, like a Prince charge.
+func ProcessTroopAttack(game *Game, attacker, target *Troop) {
     // Get current game time
     currentTime := game.GameTime
+    if currentTime - attacker.LastAttack < attacker.AttackDelay {
+        return
+    }
     
-    // Process attack from troop1 to troop2 ONLY if troop1 can attack troop2
-    if canAttack1to2 && currentTime - troop1.LastAttack >= troop1.AttackDelay {
-        // Reset attack timer
-        troop1.LastAttack = currentTime
+    // Get template for projectile and special attack information
+    template := GetTroopTemplate(attacker)
+    
+    // Check if attacker has a projectile defined; melee troops never use one
+    hasProjectile := !IsMeleeTroop(attacker) && template != nil && template.Projectile.Name != ""
+    
+    damage := attacker.Damage
+    specialAttack := template != nil && template.DamageSpecial > 0 &&
+        attacker.LastAttack < attacker.LastTargetChange
+    if specialAttack {
+        damage = ScaleStat(template.DamageSpecial, LevelMultiplier(template.Rarity, attacker.Level))
+    } else if hasProjectile {
+        damage = ShotDamage(&template.Projectile, damage, LevelMultiplier(template.Rarity, attacker.Level))
+    }
+    
+    // Reset attack timer
+    attacker.LastAttack = currentTime
+    
+    if hasProjectile {
+        // Create the projectile
+        projectile := NewProjectile(
+            &template.Projectile,
+            attacker.Position,
+            target.Position,
+            damage,
+            attacker.Team,
+            attacker.ID,
+        )
         
-        // Check if troop1 is melee - if so, never use projectiles
-        if IsMeleeTroop(troop1) {
-            // Melee troops apply damage directly
-            troop2.Health -= troop1.Damage
-            fmt.Printf("Melee attack! Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
-                       troop1.ID, troop1.Damage, troop2.ID, troop2.Health)
-            
-            // Check if troop2 is defeated
-            if troop2.Health <= 0 {
-                troop2.Active = false
-                fmt.Printf("Troop ID=%d defeated by Troop ID=%d\n", troop2.ID, troop1.ID)
-                // Clear attacking state of troop1 when target is defeated
-                troop1.IsAttacking = false
-            }
+        // Add projectile to game if it was created successfully
+        if projectile != nil {
+            projectile.Card = attacker.Card
+            projectile.Owner = attacker.Owner
+            // Homing shots follow the target and single-target shots
+            // only ever hit it
+            projectile.TargetEntity = target
+            game.Projectiles = append(game.Projectiles, *projectile)
+            logf("Troop ID=%d fires projectile at Troop ID=%d\n", attacker.ID, target.ID)
         } else {
-            // Get template for projectile information
-            template1 := GetTroopTemplate(troop1)
-            
-            // Check if troop1 has a projectile defined
-            hasProjectile := template1 != nil && template1.Projectile.Name != ""
-            
-            if hasProjectile {
-                // Create the projectile
-                projectile := CreateProjectile(
-                    template1.Projectile.Name,
-                    troop1.Position,
-                    troop2.Position,
-                    troop1.Damage,  // USE TROOP'S DAMAGE
-                    troop1.Team,
-                    troop1.ID,
-                )
-                
-                fmt.Printf("Troop ID=%d fires projectile at Troop ID=%d\n", troop1.ID, troop2.ID)
-                
-                // Set target entity for homing projectiles
-                if projectile != nil && projectile.IsHoming {
-                    projectile.TargetEntity = troop2
-                }
-                
-                // Add projectile to game if it was created successfully
-                if projectile != nil {
-                    game.Projectiles = append(game.Projectiles, *projectile)
-                } else {
-                    // Fallback to direct damage if projectile creation failed
-                    troop2.Health -= troop1.Damage
-                    fmt.Printf("Direct fallback! Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
-                               troop1.ID, troop1.Damage, troop2.ID, troop2.Health)
-                }
-            } else {
-                // Ranged troops without projectiles defined fall back to direct damage
-                troop2.Health -= troop1.Damage
-                fmt.Printf("Ranged attack! Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
-                           troop1.ID, troop1.Damage, troop2.ID, troop2.Health)
-                
-                // Check if troop2 is defeated
-                if troop2.Health <= 0 {
-                    troop2.Active = false
-                    fmt.Printf("Troop ID=%d defeated by Troop ID=%d\n", troop2.ID, troop1.ID)
-                    // Clear attacking state of troop1 when target is defeated
-                    troop1.IsAttacking = false
-                }
-            }
+            // Fallback to direct damage if projectile creation failed
+            target.Health -= damage
+            game.recordDamage(attacker.Owner, attacker.Card, damage)
+            logf("Direct fallback! Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
+                       attacker.ID, damage, target.ID, target.Health)
         }
+    } else {
+        // Melee troops, and ranged troops without projectiles, apply damage directly
+        target.Health -= damage
+        game.recordDamage(attacker.Owner, attacker.Card, damage)
+        logf("Attack! Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
+                   attacker.ID, damage, target.ID, target.Health)
     }
     
-    // Process attack from troop2 to troop1 ONLY if troop2 can attack troop1
-    if canAttack2to1 && currentTime - troop2.LastAttack >= troop2.AttackDelay {
-        // Reset attack timer
-        troop2.LastAttack = currentTime
+    // Check if target is defeated
+    if target.Health <= 0 {
+        target.Active = false
+        logf("Troop ID=%d defeated by Troop ID=%d\n", target.ID, attacker.ID)
+        // Clear attacking state of attacker when target is defeated
+        attacker.IsAttacking = false
+    } else if specialAttack {
+        RecordSpecialAttack(attacker, target)
+    }
+    
+    // Kamikaze troops are spent by their first attack
+    if IsKamikaze(attacker) {
+        selfDestruct(attacker)
+    }
+}
+
+// UpdateCombat runs one tick of combat: every troop attacks the target it
+// is locked on when in range, then buildings shoot at troops
+func UpdateCombat(game *Game) {
+    for i := range game.Troops {
+        troop := &game.Troops[i]
+        if !troop.Active || troop.IsDeploying() {
+            continue
+        }
         
-        // Check if troop2 is melee - if so, never use projectiles
-        if IsMeleeTroop(troop2) {
-            // Melee troops apply damage directly
-            troop1.Health -= troop2.Damage
-            fmt.Printf("Melee attack! Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
-                       troop2.ID, troop2.Damage, troop1.ID, troop1.Health)
-            
-            // Check if troop1 is defeated
-            if troop1.Health <= 0 {
-                troop1.Active = false
-                fmt.Printf("Troop ID=%d defeated by Troop ID=%d\n", troop1.ID, troop2.ID)
-                // Clear attacking state of troop2 when target is defeated
-                troop2.IsAttacking = false
+        if target := findTroopByID(game, troop.TargetTroopID); target != nil {
+            if CanAttackTroop(troop, target, game.Grid) {
+                troop.IsAttacking = true
+                ProcessTroopAttack(game, troop, target)
             }
-        } else {
-            // Get template for projectile information
-            template2 := GetTroopTemplate(troop2)
-            
-            // Check if troop2 has a projectile defined
-            hasProjectile := template2 != nil && template2.Projectile.Name != ""
-            
-            if hasProjectile {
-                // Create the projectile
-                projectile := CreateProjectile(
-                    template2.Projectile.Name,
-                    troop2.Position,
-                    troop1.Position,
-                    troop2.Damage,  // USE TROOP'S DAMAGE
-                    troop2.Team,
-                    troop2.ID,
-                )
-                
-                fmt.Printf("Troop ID=%d fires projectile at Troop ID=%d\n", troop2.ID, troop1.ID)
-                
-                // Set target entity for homing projectiles
-                if projectile != nil && projectile.IsHoming {
-                    projectile.TargetEntity = troop1
-                }
-                
-                // Add projectile to game if created successfully
-                if projectile != nil {
-                    game.Projectiles = append(game.Projectiles, *projectile)
-                } else {
-                    // Fallback to direct damage if projectile creation failed
-                    troop1.Health -= troop2.Damage
-                    fmt.Printf("Direct fallback! Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
-                               troop2.ID, troop2.Damage, troop1.ID, troop1.Health)
-                }
-            } else {
-                // Ranged troops without projectiles defined fall back to direct damage
-                troop1.Health -= troop2.Damage
-                fmt.Printf("Ranged attack! Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
-                           troop2.ID, troop2.Damage, troop1.ID, troop1.Health)
-                
-                // Check if troop1 is defeated
-                if troop1.Health <= 0 {
-                    troop1.Active = false
-                    fmt.Printf("Troop ID=%d defeated by Troop ID=%d\n", troop1.ID, troop2.ID)
-                    // Clear attacking state of troop2 when target is defeated
-                    troop2.IsAttacking = false
-                }
+        } else if building := troop.TargetBuilding; building != nil && building.Active {
+            if CanTroopAttackBuilding(troop, building, game.Grid) {
+                ProcessTroopBuildingCombat(game, troop, building)
             }
         }
     }
+    
+    CheckBuildingCombat(game)
 }
 
 // Also update ProcessTroopBuildingCombat to ensure consistent behavior
 func ProcessTroopBuildingCombat(game *Game, troop *Troop, building *Building) {
+    // Deploying troops don't attack yet
+    if troop.IsDeploying() {
+        return
+    }
+    
     // Set troop as attacking
     troop.IsAttacking = true
     
@@ -175,13 +146,14 @@ func ProcessTroopBuildingCombat(game *Game, troop *Troop, building *Building) {
         if IsMeleeTroop(troop) {
             // Melee troops apply damage directly
             building.Health -= troop.Damage
-            fmt.Printf("Melee attack! Troop ID=%d deals %d damage to Building ID=%d (health now: %d)\n", 
+            game.recordDamage(troop.Owner, troop.Card, troop.Damage)
+            logf("Melee attack! Troop ID=%d deals %d damage to Building ID=%d (health now: %d)\n", 
                       troop.ID, troop.Damage, building.ID, building.Health)
             
             // Check if building is destroyed
             if building.Health <= 0 {
                 building.Active = false
-                fmt.Printf("Building ID=%d destroyed by Troop ID=%d\n", building.ID, troop.ID)
+                logf("Building ID=%d destroyed by Troop ID=%d\n", building.ID, troop.ID)
                 // Clear attacking state when target is destroyed
                 troop.IsAttacking = false
             }
@@ -194,46 +166,51 @@ func ProcessTroopBuildingCombat(game *Game, troop *Troop, building *Building) {
             
             if hasProjectile {
                 // Create the projectile
-                projectile := CreateProjectile(
-                    template.Projectile.Name,
+                projectile := NewProjectile(
+                    &template.Projectile,
                     troop.Position,
                     building.Position,
-                    troop.Damage,  // USE TROOP'S DAMAGE
+                    ShotDamage(&template.Projectile, troop.Damage, LevelMultiplier(template.Rarity, troop.Level)),
                     troop.Team,
                     troop.ID,
                 )
                 
-                fmt.Printf("Troop ID=%d fires projectile at Building ID=%d\n", troop.ID, building.ID)
-                
-                // Set target entity for homing projectiles
-                if projectile != nil && projectile.IsHoming {
-                    projectile.TargetEntity = building
-                }
+                logf("Troop ID=%d fires projectile at Building ID=%d\n", troop.ID, building.ID)
                 
                 // Add projectile to game if created successfully
                 if projectile != nil {
+                    projectile.TargetEntity = building
+                    projectile.Card = troop.Card
+                    projectile.Owner = troop.Owner
                     game.Projectiles = append(game.Projectiles, *projectile)
                 } else {
                     // Fallback to direct damage if projectile creation failed
                     building.Health -= troop.Damage
-                    fmt.Printf("Direct fallback! Troop ID=%d deals %d damage to Building ID=%d (health now: %d)\n", 
+                    game.recordDamage(troop.Owner, troop.Card, troop.Damage)
+                    logf("Direct fallback! Troop ID=%d deals %d damage to Building ID=%d (health now: %d)\n", 
                                troop.ID, troop.Damage, building.ID, building.Health)
                 }
             } else {
                 // Ranged troops without projectiles defined fall back to direct damage
                 building.Health -= troop.Damage
-                fmt.Printf("Ranged attack! Troop ID=%d deals %d damage to Building ID=%d (health now: %d)\n", 
+                game.recordDamage(troop.Owner, troop.Card, troop.Damage)
+                logf("Ranged attack! Troop ID=%d deals %d damage to Building ID=%d (health now: %d)\n", 
                            troop.ID, troop.Damage, building.ID, building.Health)
                 
                 // Check if building is destroyed
                 if building.Health <= 0 {
                     building.Active = false
-                    fmt.Printf("Building ID=%d destroyed by Troop ID=%d\n", building.ID, troop.ID)
+                    logf("Building ID=%d destroyed by Troop ID=%d\n", building.ID, troop.ID)
                     // Clear attacking state when target is destroyed
                     troop.IsAttacking = false
                 }
             }
         }
+        
+        // Kamikaze troops are spent by their first attack
+        if IsKamikaze(troop) {
+            selfDestruct(troop)
+        }
     }
 }
 
@@ -243,19 +220,23 @@ func IsMeleeTroop(troop *Troop) bool {
     template := GetTroopTemplate(troop)
     if template != nil {
         // Use template range if available
-        return template.Range <= 1.0
+        return IsMeleeRange(template.Range)
     }
     
     // Fallback to troop's range attribute
-    return troop.Range <= 1.0
+    return IsMeleeRange(troop.Range)
 }
 
 func ProcessBuildingTroopCombat(game *Game, building *Building, troop *Troop, buildingTeam int) {
     // Get current game time
     currentTime := game.GameTime
     
-    // Buildings attack more slowly (every 60 ticks = 1 second)
-    if currentTime - building.LastAttack >= 60 {
+    // Buildings attack at their hit speed, falling back to the king tower's
+    attackDelay := building.AttackDelay
+    if attackDelay <= 0 {
+        attackDelay = SecondsToTicks(kingTowerHitSpeed)
+    }
+    if currentTime - building.LastAttack >= attackDelay {
         // Reset attack timer
         building.LastAttack = currentTime
         
@@ -267,28 +248,36 @@ func ProcessBuildingTroopCombat(game *Game, building *Building, troop *Troop, bu
 
         // Create projectile
         projectile := CreateProjectile(
+            game.Catalog,
             projectileType,
             building.Position,
             troop.Position,
             building.Damage,
+            building.DamageMultiplier,
             buildingTeam,
             0, // Buildings don't have IDs
         )
         
         // Only add valid projectiles to game
         if projectile != nil {
+            // Building shots always hit the troop they were aimed at
+            projectile.TargetEntity = troop
+            // Deployed buildings are credited to their card; towers have no name
+            projectile.Card = building.Name
+            projectile.Owner = building.Owner
             game.Projectiles = append(game.Projectiles, *projectile)
-            fmt.Printf("Building ID=%d fires projectile at Troop ID=%d\n", building.ID, troop.ID)
+            logf("Building ID=%d fires projectile at Troop ID=%d\n", building.ID, troop.ID)
         } else {
             // Apply damage directly if projectile creation failed
             troop.Health -= building.Damage
-            fmt.Printf("Direct attack! Building ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
+            game.recordDamage(building.Owner, building.Name, building.Damage)
+            logf("Direct attack! Building ID=%d deals %d damage to Troop ID=%d (health now: %d)\n", 
                       building.ID, building.Damage, troop.ID, troop.Health)
             
             // Check if troop is defeated
             if troop.Health <= 0 {
                 troop.Active = false
-                fmt.Printf("Troop ID=%d defeated by Building ID=%d\n", troop.ID, building.ID)
+                logf("Troop ID=%d defeated by Building ID=%d\n", troop.ID, building.ID)
             }
         }
     }
@@ -296,7 +285,7 @@ func ProcessBuildingTroopCombat(game *Game, building *Building, troop *Troop, bu
 
 
 func ClearInvalidAttackStates(game *Game) {
-    // For each troop, check if it's still in combat range of any enemy
+    // For each troop, check it is still in range of the target it is locked on
     for i := range game.Troops {
         troop := &game.Troops[i]
         
@@ -305,56 +294,30 @@ func ClearInvalidAttackStates(game *Game) {
             continue
         }
         
-        // Assume not in combat until we find a valid target
+        // Assume not in combat until the locked target checks out
         inCombat := false
         
-        // Check against other troops
-        for j := range game.Troops {
-            // Skip self, inactive troops, or same team troops
-            if i == j || !game.Troops[j].Active || game.Troops[i].Team == game.Troops[j].Team {
-                continue
-            }
-            
-            // Check if in attack range
-            if CanAttackTroop(troop, &game.Troops[j], game.Grid) {
-                inCombat = true
-                break
-            }
-        }
-        
-        // Also check for valid building targets
-        if !inCombat && !TargetsOnlyTroops(troop) {
-            // Get enemy team
-            enemyTeam := 1 - troop.Team
-            
-            // Check king building
-            kingBuilding := &game.Players[enemyTeam].KingBuilding.Building
-            if kingBuilding.Active && CanTroopAttackBuilding(troop, kingBuilding, game.Grid) {
-                inCombat = true
-            }
-            
-            // Check regular buildings
-            if !inCombat {
-                for j := range game.Players[enemyTeam].Buildings {
-                    building := &game.Players[enemyTeam].Buildings[j]
-                    if building.Active && CanTroopAttackBuilding(troop, building, game.Grid) {
-                        inCombat = true
-                        break
-                    }
-                }
-            }
+        if target := findTroopByID(game, troop.TargetTroopID); target != nil {
+            inCombat = CanAttackTroop(troop, target, game.Grid)
+        } else if building := troop.TargetBuilding; building != nil && building.Active {
+            inCombat = CanTroopAttackBuilding(troop, building, game.Grid)
         }
         
-        // If not in combat with any valid target, clear attacking state
+        // If not in combat with a valid target, clear attacking state
         if !inCombat {
             troop.IsAttacking = false
-            fmt.Printf("Cleared attacking state for Troop ID=%d (no valid targets in range)\n", troop.ID)
+            logf("Cleared attacking state for Troop ID=%d (no valid targets in range)\n", troop.ID)
         }
     }
 }
 
 // Add this new function to detect if a troop can attack another troop
 func CanAttackTroop(troop1 *Troop, troop2 *Troop, grid *GridSystem) bool {
+	// Troops can't attack until they have finished deploying
+	if troop1.IsDeploying() {
+		return false
+	}
+	
 	// Check if troop types are compatible for combat
 	// i.e., flying troops can only be attacked by troops that can attack air
 	// ground troops can only be attacked by troops that can attack ground
@@ -378,16 +341,21 @@ func CanAttackTroop(troop1 *Troop, troop2 *Troop, grid *GridSystem) bool {
 	// Convert attack range from grid cells to pixels
 	attackRangePixels := troop1.Range * grid.CellWidth
 	
-	// Consider the target troop's size in the calculation
-	targetTroopRadius := troop2.Size / 2
+	// Range is measured between the edges of the two bodies, since
+	// collisions keep them from overlapping
+	edgeDistance := dist - troop1.Size/2 - troop2.Size/2
 	
 	// Check if troop1 can attack troop2
-	// We can attack if distance - targetRadius <= attackRange
-	return dist - targetTroopRadius <= attackRangePixels
+	return edgeDistance <= attackRangePixels
 }
 
 // CanTroopAttackBuilding checks if a troop can attack a specific building
 func CanTroopAttackBuilding(troop *Troop, building *Building, grid *GridSystem) bool {
+    // Troops can't attack until they have finished deploying
+    if troop.IsDeploying() {
+        return false
+    }
+    
     // Skip if troop only targets other troops
     if TargetsOnlyTroops(troop) {
         return false
@@ -403,15 +371,23 @@ func CanTroopAttackBuilding(troop *Troop, building *Building, grid *GridSystem)
     width, height := building.GetPixelDimensions(grid)
     buildingRadius := math.Max(width, height) / 2
     
-    // A troop can attack if it's close enough (considering building size)
-    return dist - buildingRadius <= attackRange
+    // A troop can attack if it's close enough (considering both sizes)
+    return dist - buildingRadius - troop.Size/2 <= attackRange
 }
 
-// FindTroopInBuildingRange finds the closest enemy troop in a building's attack range
+// FindTroopInBuildingRange returns the enemy troop a building should shoot.
+// Like troops, buildings stay locked on their target while it is in range and
+// only then fall back to the closest enemy troop.
 func FindTroopInBuildingRange(game *Game, building *Building, buildingTeam int) *Troop {
     // Calculate attack range in pixels
     attackRange := building.Range * game.Grid.CellWidth
     
+    if locked := findTroopByID(game, building.TargetTroopID); locked != nil {
+        if Distance(building.Position, locked.Position) <= attackRange && !(building.GroundOnly && IsFlyingTroop(locked)) {
+            return locked
+        }
+    }
+    
     var closestTroop *Troop
     closestDistance := attackRange + 1 // Start just outside range
     
@@ -423,8 +399,10 @@ func FindTroopInBuildingRange(game *Game, building *Building, buildingTeam int)
             continue
         }
         
-        // Skip flying troops if building can't attack air (placeholder logic)
-        // For now assume all buildings can attack all troop types
+        // Skip flying troops if building can't attack air
+        if building.GroundOnly && IsFlyingTroop(troop) {
+            continue
+        }
         
         // Calculate distance
         dist := Distance(building.Position, troop.Position)
@@ -436,15 +414,19 @@ func FindTroopInBuildingRange(game *Game, building *Building, buildingTeam int)
         }
     }
     
+    building.TargetTroopID = 0
+    if closestTroop != nil {
+        building.TargetTroopID = closestTroop.ID
+    }
     return closestTroop
 }
 
 // CheckBuildingCombat handles buildings attacking troops
 func CheckBuildingCombat(game *Game) {
     // Check each team's buildings
-    for team := 0; team < 2; team++ {
+    for team := range game.Teams {
         // Check king building
-        kingBuilding := &game.Players[team].KingBuilding.Building
+        kingBuilding := &game.Teams[team].KingBuilding.Building
         if kingBuilding.Active {
             // Find closest enemy troop in range
             target := FindTroopInBuildingRange(game, kingBuilding, team)
@@ -453,10 +435,9 @@ func CheckBuildingCombat(game *Game) {
             }
         }
         
-        // Check regular buildings
-        for i := range game.Players[team].Buildings {
-            building := &game.Players[team].Buildings[i]
-            if building.Active {
+        // Check princess towers and deployed buildings
+        for _, building := range teamBuildings(game, team) {
+            if building.Active && !building.IsDeploying() {
                 // Find closest enemy troop in range
                 target := FindTroopInBuildingRange(game, building, team)
                 if target != nil {
@@ -0,0 +1,259 @@
+// command.go
+package clashgame
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// CommandType identifies the kind of action a player is requesting
+type CommandType int
+
+const (
+	CommandDeployCard CommandType = iota // Deploy a troop card at a cell
+	CommandCastSpell                     // Cast a spell projectile at a cell
+	CommandEmote                         // Show an emote
+	CommandSurrender                     // Concede the match
+)
+
+// NoWinner marks a game that has not been decided yet
+const NoWinner = -1
+
+// String returns a readable name for logging
+func (t CommandType) String() string {
+	switch t {
+	case CommandDeployCard:
+		return "deploy"
+	case CommandCastSpell:
+		return "spell"
+	case CommandEmote:
+		return "emote"
+	case CommandSurrender:
+		return "surrender"
+	default:
+		return fmt.Sprintf("command(%d)", int(t))
+	}
+}
+
+// Command is a single player action. Local input, network clients, bots and
+// replays all produce Commands; the simulation validates and applies them at
+// tick boundaries so every source goes through the same path.
+type Command struct {
+	Type   CommandType `json:"type"`
+	Player int         `json:"player"` // Player issuing the command; their team follows from it
+	Tick   int         `json:"tick"`   // Tick to execute on; 0 means the next tick
+	Card   string      `json:"card"`   // Card name for deploy/spell
+	Col    int         `json:"col"`    // Target cell for deploy/spell
+	Row    int         `json:"row"`
+	Emote  string      `json:"emote"` // Emote name for CommandEmote
+
+	seq int // Submission order, keeps same-tick commands stable
+}
+
+// CommandQueue buffers submitted commands until the simulation reaches their
+// tick. It has its own lock so producers never wait on a running tick.
+type CommandQueue struct {
+	mu      sync.Mutex
+	pending []Command
+	nextSeq int
+}
+
+// NewCommandQueue creates an empty queue
+func NewCommandQueue() *CommandQueue {
+	return &CommandQueue{}
+}
+
+// Push adds a command to the queue
+func (q *CommandQueue) Push(cmd Command) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cmd.seq = q.nextSeq
+	q.nextSeq++
+	q.pending = append(q.pending, cmd)
+}
+
+// PopDue removes and returns every command scheduled at or before tick,
+// ordered by tick and then by submission order
+func (q *CommandQueue) PopDue(tick int) []Command {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var due []Command
+	remaining := q.pending[:0]
+	for _, cmd := range q.pending {
+		if cmd.Tick <= tick {
+			due = append(due, cmd)
+		} else {
+			remaining = append(remaining, cmd)
+		}
+	}
+	q.pending = remaining
+
+	sort.SliceStable(due, func(i, j int) bool {
+		if due[i].Tick != due[j].Tick {
+			return due[i].Tick < due[j].Tick
+		}
+		return due[i].seq < due[j].seq
+	})
+	return due
+}
+
+// Len returns the number of commands still waiting
+func (q *CommandQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// SubmitCommand queues a command for the simulation. It only touches the
+// queue's own lock, so it is safe to call from Ebiten's Update while the game
+// lock is held as well as from other goroutines.
+func (g *Game) SubmitCommand(cmd Command) {
+	g.Commands.Push(cmd)
+}
+
+// ProcessCommands applies every command due on the current tick. It is called
+// by Tick with the game lock held. Applied commands are appended to
+// CommandLog stamped with the tick they ran on, so a replay can feed the log
+// back through the same queue.
+func ProcessCommands(game *Game) {
+	for _, cmd := range game.Commands.PopDue(game.GameTime) {
+		if err := ValidateCommand(game, cmd); err != nil {
+			logf("Rejected %s command from player %d: %v\n", cmd.Type, cmd.Player, err)
+			continue
+		}
+
+		cmd.Tick = game.GameTime
+		applyCommand(game, cmd)
+		game.CommandLog = append(game.CommandLog, cmd)
+	}
+}
+
+// ValidateCommand checks that a command can be applied to the current state
+func ValidateCommand(game *Game, cmd Command) error {
+	if cmd.Player < 0 || cmd.Player >= len(game.Players) {
+		return fmt.Errorf("invalid player %d", cmd.Player)
+	}
+	player := &game.Players[cmd.Player]
+
+	switch cmd.Type {
+	case CommandDeployCard, CommandCastSpell:
+		card, exists := game.Catalog.Card(cmd.Card)
+		if !exists {
+			return fmt.Errorf("unknown card %q", cmd.Card)
+		}
+		if card.IsSpell() != (cmd.Type == CommandCastSpell) {
+			return fmt.Errorf("card %q can't be used for a %s command", cmd.Card, cmd.Type)
+		}
+		if player.HasHand() && !player.InHand(cmd.Card) {
+			return fmt.Errorf("card %q is not in hand", cmd.Card)
+		}
+		if player.Elixir < float64(card.ElixirCost) {
+			return fmt.Errorf("not enough elixir for %s (%.1f/%d)", cmd.Card, player.Elixir, card.ElixirCost)
+		}
+		if cmd.Type == CommandDeployCard && !CanDeployAt(game, player.Team, cmd.Col, cmd.Row) {
+			return fmt.Errorf("cell (%d,%d) is outside team %d's deploy zone", cmd.Col, cmd.Row, player.Team)
+		}
+		if card.IsBuilding() {
+			return validateBuildingSite(game, card, cmd.Col, cmd.Row)
+		}
+		return validateTargetCell(game, cmd.Col, cmd.Row)
+	case CommandEmote:
+		if cmd.Emote == "" {
+			return fmt.Errorf("empty emote")
+		}
+	case CommandSurrender:
+		// Always allowed while the game is running
+	default:
+		return fmt.Errorf("unknown command type %d", int(cmd.Type))
+	}
+
+	return nil
+}
+
+// validateTargetCell checks a deploy/spell target lies on a usable cell
+func validateTargetCell(game *Game, col, row int) error {
+	if col < 0 || col >= game.Grid.Columns || row < 0 || row >= game.Grid.Rows {
+		return fmt.Errorf("cell (%d,%d) is outside the arena", col, row)
+	}
+	if game.Grid.TileMap != nil && !game.Grid.IsWalkableTile(col, row) {
+		return fmt.Errorf("cell (%d,%d) is not walkable", col, row)
+	}
+	if game.Grid.GetCellType(col, row) == CellTypeWater {
+		return fmt.Errorf("cell (%d,%d) is water", col, row)
+	}
+	return nil
+}
+
+// validateBuildingSite checks a building card's whole footprint is free
+func validateBuildingSite(game *Game, card *CardDefinition, col, row int) error {
+	if err := validateTargetCell(game, col, row); err != nil {
+		return err
+	}
+	template, exists := game.Catalog.Building(card.Building)
+	if !exists {
+		return fmt.Errorf("unknown building %q", card.Building)
+	}
+	building := NewDeployedBuilding(template, game.Grid.CellToPosition(col, row), 0, MinCardLevel, game.Grid)
+	if !game.Grid.IsFootprintFree(building) {
+		return fmt.Errorf("no room for %s at (%d,%d)", card.Building, col, row)
+	}
+	return nil
+}
+
+// applyCommand performs an already validated command
+func applyCommand(game *Game, cmd Command) {
+	player := &game.Players[cmd.Player]
+
+	switch cmd.Type {
+	case CommandDeployCard:
+		// A card that fails to deploy costs nothing and stays in the hand
+		card, _ := game.Catalog.Card(cmd.Card)
+		if err := DeployCard(game, cmd.Card, cmd.Col, cmd.Row, cmd.Player); err != nil {
+			logf("Error deploying %s: %v\n", cmd.Card, err)
+			return
+		}
+		player.Elixir -= float64(card.ElixirCost)
+		player.playCard(cmd.Card)
+
+	case CommandCastSpell:
+		card, _ := game.Catalog.Card(cmd.Card)
+
+		// Spells are launched from the caster's king tower and deal their
+		// template damage at the caster's card level
+		target := game.Grid.CellToPosition(cmd.Col, cmd.Row)
+		multiplier := 1.0
+		if template, exists := game.Catalog.Projectile(card.Spell); exists {
+			multiplier = LevelMultiplier(template.Rarity, player.CardLevel(card.Name))
+		}
+		projectile := CreateProjectile(
+			game.Catalog,
+			card.Spell,
+			game.Teams[player.Team].KingBuilding.Position,
+			target,
+			0,
+			multiplier,
+			player.Team,
+			0,
+		)
+		if projectile == nil {
+			logf("Error casting %s: no projectile %s\n", cmd.Card, card.Spell)
+			return
+		}
+		projectile.Card = card.Name
+		projectile.Owner = cmd.Player
+		game.Projectiles = append(game.Projectiles, *projectile)
+		player.Elixir -= float64(card.ElixirCost)
+		player.playCard(cmd.Card)
+
+	case CommandEmote:
+		player.LastEmote = cmd.Emote
+		player.LastEmoteTick = game.GameTime
+
+	case CommandSurrender:
+		// One player giving up concedes for the whole team
+		game.endMatch(EnemyTeam(player.Team), fmt.Sprintf("Player %d surrendered for team %d", cmd.Player, player.Team))
+	}
+}
@@ -0,0 +1,112 @@
+// command_test.go
+package clashgame
+
+import (
+	"reflect"
+	"testing"
+)
+
+// cannonGame starts a game where player 1 holds a Cannon and full elixir,
+// and returns a validated command deploying it
+func cannonGame(t *testing.T) (*Game, Command) {
+	t.Helper()
+	game := NewGame(testCatalog(t), nil, DefaultGameConfig())
+	player := &game.Players[1]
+	player.Deck = nil
+	for _, name := range DefaultBotDeck {
+		player.Deck = append(player.Deck, DeckCard{Name: name, Level: TournamentLevelCap})
+	}
+	player.dealHand()
+	if !player.InHand("Cannon") {
+		player.Hand[0] = "Cannon"
+	}
+	player.Elixir = float64(player.ElixirMax)
+
+	for row := 0; row < game.Grid.Rows; row++ {
+		for col := 0; col < game.Grid.Columns; col++ {
+			cmd := Command{Type: CommandDeployCard, Player: 1, Card: "Cannon", Col: col, Row: row}
+			if ValidateCommand(game, cmd) == nil {
+				return game, cmd
+			}
+		}
+	}
+	t.Fatal("nowhere to deploy a Cannon")
+	return nil, Command{}
+}
+
+// TestFailedDeployCostsNothing applies a validated Cannon deploy after
+// another building has taken its site. The deploy fails, so the player
+// keeps their elixir and the Cannon stays in their hand.
+func TestFailedDeployCostsNothing(t *testing.T) {
+	game, cmd := cannonGame(t)
+	if err := PlaceBuilding(game, "Cannon", cmd.Col, cmd.Row, 1, TournamentLevelCap); err != nil {
+		t.Fatal(err)
+	}
+	player := &game.Players[1]
+	buildings := len(game.DeployedBuildings)
+	elixir := player.Elixir
+	hand := append([]string(nil), player.Hand...)
+	applyCommand(game, cmd)
+
+	if len(game.DeployedBuildings) != buildings {
+		t.Fatal("a second Cannon was placed on an occupied site")
+	}
+	if player.Elixir != elixir {
+		t.Errorf("elixir went from %.1f to %.1f for a failed deploy", elixir, player.Elixir)
+	}
+	if !reflect.DeepEqual(player.Hand, hand) {
+		t.Errorf("hand changed from %v to %v for a failed deploy", hand, player.Hand)
+	}
+
+	// On a free site the same deploy is charged and cycles the card
+	game, cmd = cannonGame(t)
+	player = &game.Players[1]
+	applyCommand(game, cmd)
+	if len(game.DeployedBuildings) != 1 {
+		t.Fatal("the Cannon wasn't placed")
+	}
+	if player.Elixir != elixir-3 {
+		t.Errorf("elixir went from %.1f to %.1f for a 3 elixir Cannon", elixir, player.Elixir)
+	}
+	if player.InHand("Cannon") {
+		t.Error("the Cannon is still in hand after deploying it")
+	}
+}
+
+// TestCardBarShowsHand checks the card bar lists the local player's hand,
+// spells included, and follows it as cards are played
+func TestCardBarShowsHand(t *testing.T) {
+	game := NewGame(testCatalog(t), nil, DefaultGameConfig())
+	bar := NewTroopSelectionSystem(game.Catalog)
+	bar.SetHand(game.localHand())
+	if bar.ShowingHand || len(bar.TroopNames) <= HandSize {
+		t.Fatalf("a player without a deck got %d cards in the bar, want the catalog", len(bar.TroopNames))
+	}
+
+	player := &game.Players[0]
+	for _, name := range DefaultBotDeck {
+		player.Deck = append(player.Deck, DeckCard{Name: name, Level: TournamentLevelCap})
+	}
+	player.dealHand()
+	player.Hand[3] = "Arrows"
+	player.Elixir = float64(player.ElixirMax)
+	bar.SetHand(game.localHand())
+	if !reflect.DeepEqual(bar.TroopNames, player.Hand) {
+		t.Fatalf("bar lists %v, want the hand %v", bar.TroopNames, player.Hand)
+	}
+	if bar.NextCard != "Minions" {
+		t.Errorf("next card is %q, want Minions", bar.NextCard)
+	}
+
+	// Playing the selected card cycles it out of the bar and the selection
+	bar.SelectedTroop = "Arrows"
+	bar.DeploySelectedTroop(game, game.Grid.Columns/2, game.Grid.Rows/4, 0)
+	ProcessCommands(game)
+	bar.SetHand(game.localHand())
+	if player.InHand("Arrows") {
+		t.Fatal("Arrows weren't played")
+	}
+	if !reflect.DeepEqual(bar.TroopNames, player.Hand) || bar.SelectedTroop != "" {
+		t.Errorf("bar lists %v with %q selected after playing Arrows, want %v with nothing selected", bar.TroopNames, bar.SelectedTroop, player.Hand)
+	}
+}
@@ -0,0 +1,262 @@
+// csvschema.go
+package clashgame
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Column types declared in the second row of the data CSVs
+const (
+	csvTypeString  = "string"
+	csvTypeInt     = "int"
+	csvTypeBoolean = "boolean"
+)
+
+// CSVError points at the file, row and column a CSV problem was found in.
+// Row is the line in the file, 0 when the problem is with a whole column.
+type CSVError struct {
+	Path   string
+	Row    int
+	Column string
+	Err    error
+}
+
+func (e *CSVError) Error() string {
+	location := filepath.Base(e.Path)
+	if e.Row > 0 {
+		location += fmt.Sprintf(" row %d", e.Row)
+	}
+	if e.Column != "" {
+		location += fmt.Sprintf(" column %s", e.Column)
+	}
+	return location + ": " + e.Err.Error()
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// CSVTable is a data CSV in the format of troops.csv: a header row, a row
+// declaring each column's type and then one row per entry. Every cell has
+// already been checked against its column's declared type.
+type CSVTable struct {
+	Path    string
+	Header  []string
+	Types   []string   // Declared type per column, lower case
+	Rows    [][]string // Data rows
+	Lines   []int      // Line in the file each data row starts on
+	columns map[string]int
+}
+
+// ReadCSVTable reads a data CSV from fsys and validates every non-empty cell
+// against its declared type. All bad cells are reported together.
+func ReadCSVTable(fsys fs.FS, path string) (*CSVTable, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	table := &CSVTable{Path: path, columns: make(map[string]int)}
+
+	if table.Header, err = reader.Read(); err != nil {
+		return nil, &CSVError{Path: path, Row: 1, Err: fmt.Errorf("failed to read header: %v", err)}
+	}
+	for i, column := range table.Header {
+		table.columns[column] = i
+	}
+
+	types, err := reader.Read()
+	if err != nil {
+		return nil, &CSVError{Path: path, Row: 2, Err: fmt.Errorf("failed to read column types: %v", err)}
+	}
+	var errs []error
+	for i, declared := range types {
+		declared = strings.ToLower(declared)
+		switch declared {
+		case csvTypeString, csvTypeInt, csvTypeBoolean:
+		default:
+			errs = append(errs, &CSVError{Path: path, Row: 2, Column: table.Header[i], Err: fmt.Errorf("unknown column type %q", declared)})
+		}
+		table.Types = append(table.Types, declared)
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Parse errors already carry their line
+			return nil, &CSVError{Path: path, Err: err}
+		}
+		line, _ := reader.FieldPos(0)
+
+		for i, cell := range record {
+			if err := checkCSVCell(table.Types[i], cell); err != nil {
+				errs = append(errs, &CSVError{Path: path, Row: line, Column: table.Header[i], Err: err})
+			}
+		}
+		table.Rows = append(table.Rows, record)
+		table.Lines = append(table.Lines, line)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return table, nil
+}
+
+// Cell returns the raw cell of data row i in the named column, false when
+// the CSV has no such column
+func (t *CSVTable) Cell(i int, column string) (string, bool) {
+	index, exists := t.columns[column]
+	if !exists {
+		return "", false
+	}
+	if record := t.Rows[i]; index < len(record) {
+		return record[index], true
+	}
+	return "", true
+}
+
+// skipCSVRow reports whether a data row is a blank separator or marked
+// NOTINUSE, which the loaders ignore
+func skipCSVRow(record []string) bool {
+	return len(record) == 0 || record[0] == "" || strings.Contains(record[0], "NOTINUSE")
+}
+
+// checkCSVCell checks a cell holds a value of the declared type. Empty
+// cells mean the zero value and are always valid.
+func checkCSVCell(declared, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	switch declared {
+	case csvTypeInt:
+		if _, err := strconv.Atoi(cell); err != nil {
+			return fmt.Errorf("%q is not an int", cell)
+		}
+	case csvTypeBoolean:
+		if _, err := parseCSVBool(cell); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCSVBool reads a boolean cell, which the CSVs write as "true"
+func parseCSVBool(cell string) (bool, error) {
+	switch strings.ToLower(cell) {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	}
+	return false, fmt.Errorf("%q is not a boolean", cell)
+}
+
+// Unmarshal fills the tagged fields of the struct dst points to from data
+// row i. A field tagged `csv:"HitSpeed"` reads that column. Float fields
+// convert the raw int with a `unit:"ms"` tag (see csvUnits) or divide it by
+// a `scale:"100"` tag. Fields must match their column's declared type:
+// strings read string columns, ints read int columns, floats read converted
+// int columns and bools read boolean columns or int columns as non-zero.
+func (t *CSVTable) Unmarshal(i int, dst interface{}) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal target must be a pointer to a struct, got %T", dst)
+	}
+	value = value.Elem()
+	record := t.Rows[i]
+
+	var errs []error
+	for f := 0; f < value.NumField(); f++ {
+		field := value.Type().Field(f)
+		column, tagged := field.Tag.Lookup("csv")
+		if !tagged {
+			continue
+		}
+		index, exists := t.columns[column]
+		if !exists {
+			errs = append(errs, &CSVError{Path: t.Path, Column: column, Err: fmt.Errorf("no such column for %s", field.Name)})
+			continue
+		}
+		cell := ""
+		if index < len(record) {
+			cell = record[index]
+		}
+		if err := setCSVField(value.Field(f), field, t.Types[index], cell); err != nil {
+			errs = append(errs, &CSVError{Path: t.Path, Row: t.Lines[i], Column: column, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setCSVField stores an already validated cell in a struct field
+func setCSVField(target reflect.Value, field reflect.StructField, declared, cell string) error {
+	scale := 1.0
+	if tag, exists := field.Tag.Lookup("scale"); exists {
+		parsed, err := strconv.ParseFloat(tag, 64)
+		if err != nil || parsed == 0 {
+			return fmt.Errorf("bad scale %q on %s", tag, field.Name)
+		}
+		scale = parsed
+	}
+	convert := func(value float64) float64 { return value / scale }
+	if unit, exists := field.Tag.Lookup("unit"); exists {
+		conversion, known := csvUnits[unit]
+		if !known {
+			return fmt.Errorf("unknown unit %q on %s", unit, field.Name)
+		}
+		convert = conversion
+	}
+
+	mismatch := fmt.Errorf("%s column can't fill %s field %s", declared, target.Kind(), field.Name)
+	switch target.Kind() {
+	case reflect.String:
+		if declared != csvTypeString {
+			return mismatch
+		}
+		target.SetString(cell)
+
+	case reflect.Int:
+		if declared != csvTypeInt {
+			return mismatch
+		}
+		number, _ := strconv.Atoi(cell)
+		target.SetInt(int64(number))
+
+	case reflect.Float64:
+		if declared != csvTypeInt {
+			return mismatch
+		}
+		number, _ := strconv.Atoi(cell)
+		target.SetFloat(convert(float64(number)))
+
+	case reflect.Bool:
+		switch declared {
+		case csvTypeBoolean:
+			flag, _ := parseCSVBool(cell)
+			target.SetBool(flag)
+		case csvTypeInt:
+			number, _ := strconv.Atoi(cell)
+			target.SetBool(number != 0)
+		default:
+			return mismatch
+		}
+
+	default:
+		return fmt.Errorf("unsupported field type %s for %s", target.Kind(), field.Name)
+	}
+	return nil
+}
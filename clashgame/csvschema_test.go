@@ -0,0 +1,125 @@
+// csvschema_test.go
+package clashgame
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"testing/fstest"
+)
+
+// readTestTable reads CSV text as a data table
+func readTestTable(text string) (*CSVTable, error) {
+	fsys := fstest.MapFS{"test.csv": &fstest.MapFile{Data: []byte(text)}}
+	return ReadCSVTable(fsys, "test.csv")
+}
+
+// TestReadCSVTableBadCells checks each kind of bad cell is reported at its
+// row and column
+func TestReadCSVTableBadCells(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		row    int
+		column string
+	}{
+		{"bad int", "Name,Hitpoints\nString,Int\nKnight,lots\n", 3, "Hitpoints"},
+		{"bad bool", "Name,Flying\nString,Boolean\nMinion,yes\n", 3, "Flying"},
+		{"unknown type", "Name,Hitpoints\nString,Float\nKnight,1\n", 2, "Hitpoints"},
+	}
+	for _, tt := range tests {
+		_, err := readTestTable(tt.text)
+		var csvErr *CSVError
+		if !errors.As(err, &csvErr) {
+			t.Errorf("%s: got %v, want a CSVError", tt.name, err)
+			continue
+		}
+		if csvErr.Row != tt.row || csvErr.Column != tt.column {
+			t.Errorf("%s: reported at row %d column %s, want row %d column %s", tt.name, csvErr.Row, csvErr.Column, tt.row, tt.column)
+		}
+	}
+
+	// Empty cells are the zero value of any type
+	if _, err := readTestTable("Name,Hitpoints,Flying\nString,Int,Boolean\nKnight,,\n"); err != nil {
+		t.Errorf("empty cells were rejected: %v", err)
+	}
+}
+
+// TestUnmarshalConversions checks the scale and unit tags convert raw ints
+// and bools read from boolean and int columns
+func TestUnmarshalConversions(t *testing.T) {
+	table, err := readTestTable("Name,HitSpeed,Range,DamagePercent,Flying,Ranged\n" +
+		"String,Int,Int,Int,Boolean,Int\n" +
+		"Archer,1200,5000,75,TRUE,1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var row struct {
+		Name          string  `csv:"Name"`
+		HitSpeed      float64 `csv:"HitSpeed" unit:"ms"`
+		Range         float64 `csv:"Range" unit:"distance"`
+		DamagePercent float64 `csv:"DamagePercent" scale:"100"`
+		Flying        bool    `csv:"Flying"`
+		Ranged        bool    `csv:"Ranged"`
+		Unread        int
+	}
+	if err := table.Unmarshal(0, &row); err != nil {
+		t.Fatal(err)
+	}
+
+	if row.Name != "Archer" || !row.Flying || !row.Ranged {
+		t.Errorf("read %+v", row)
+	}
+	floats := []struct {
+		field     string
+		got, want float64
+	}{
+		{"HitSpeed", row.HitSpeed, 1.2},
+		{"Range", row.Range, TilesToCells(5)},
+		{"DamagePercent", row.DamagePercent, 0.75},
+	}
+	for _, tt := range floats {
+		if math.Abs(tt.got-tt.want) > 1e-9 {
+			t.Errorf("%s = %g, want %g", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+// TestUnmarshalErrors checks fields that can't be filled from the table are
+// reported with their column
+func TestUnmarshalErrors(t *testing.T) {
+	table, err := readTestTable("Name,Hitpoints\nString,Int\nKnight,1400\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		dst    interface{}
+		column string
+	}{
+		{"missing column", &struct {
+			Speed int `csv:"Speed"`
+		}{}, "Speed"},
+		{"type mismatch", &struct {
+			Hitpoints string `csv:"Hitpoints"`
+		}{}, "Hitpoints"},
+		{"unknown unit", &struct {
+			Hitpoints float64 `csv:"Hitpoints" unit:"furlongs"`
+		}{}, "Hitpoints"},
+		{"zero scale", &struct {
+			Hitpoints float64 `csv:"Hitpoints" scale:"0"`
+		}{}, "Hitpoints"},
+	}
+	for _, tt := range tests {
+		err := table.Unmarshal(0, tt.dst)
+		var csvErr *CSVError
+		if !errors.As(err, &csvErr) {
+			t.Errorf("%s: got %v, want a CSVError", tt.name, err)
+			continue
+		}
+		if csvErr.Column != tt.column {
+			t.Errorf("%s: reported on column %s, want %s", tt.name, csvErr.Column, tt.column)
+		}
+	}
+}
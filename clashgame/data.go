@@ -0,0 +1,52 @@
+// data.go
+package clashgame
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+// embeddedData is the game data shipped inside the binary, so `go run`, an
+// installed binary and the tools all start from the same files wherever
+// they run
+//
+//go:embed csv/troops.csv csv/projectiles.csv csv/buildings.csv csv/tilemap.csv arenas
+var embeddedData embed.FS
+
+// Paths of the game files inside a data FS. An external data directory is
+// laid out the same way as this package's directory.
+const (
+	TroopsCSVPath      = "csv/troops.csv"
+	ProjectilesCSVPath = "csv/projectiles.csv"
+	BuildingsCSVPath   = "csv/buildings.csv"
+	ClassicArenaPath   = "arenas/classic.json"
+)
+
+// DataDirEnv names the environment variable pointing at an external data
+// directory to use instead of the embedded data
+const DataDirEnv = "CLASH_DATA_DIR"
+
+// DefaultData returns the embedded game data
+func DefaultData() fs.FS {
+	return embeddedData
+}
+
+// DataDir picks the external data directory: the given one (usually a
+// -data flag) if set, otherwise $CLASH_DATA_DIR. Empty means the embedded
+// data.
+func DataDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	return os.Getenv(DataDirEnv)
+}
+
+// OpenData returns the data FS for a directory from DataDir, the embedded
+// data when it is empty
+func OpenData(dir string) fs.FS {
+	if dir == "" {
+		return DefaultData()
+	}
+	return os.DirFS(dir)
+}
@@ -0,0 +1,121 @@
+// deploy.go
+package clashgame
+
+import "math"
+
+// Effect is a short-lived visual marker in the arena, such as a troop's
+// SpawnEffect when it lands
+type Effect struct {
+	Name      string
+	Position  Position
+	Radius    float64 // Radius in pixels
+	StartTick int
+	Duration  int // Ticks the effect stays visible
+}
+
+// spawnEffectDuration is how long a landing effect is drawn for
+const spawnEffectDuration = 10
+
+// IsDeploying reports whether the troop is still being deployed. Deploying
+// troops are visible and can be targeted, but they don't move or attack.
+func (t *Troop) IsDeploying() bool {
+	return t.DeployTicks > 0
+}
+
+// IsDeploying reports whether a building placed from a card is still being
+// set up. Like deploying troops it can be attacked but doesn't shoot yet.
+func (b *Building) IsDeploying() bool {
+	return b.DeployTicks > 0
+}
+
+// UpdateDeployingTroops counts down the deploy timers of troops and placed
+// buildings and lands troops whose timer ran out. It also drops effects that
+// have finished playing.
+func UpdateDeployingTroops(game *Game) {
+	for i := range game.Troops {
+		troop := &game.Troops[i]
+		if !troop.Active || !troop.IsDeploying() {
+			continue
+		}
+
+		troop.DeployTicks--
+		if troop.DeployTicks == 0 {
+			landTroop(game, troop)
+		}
+	}
+	for _, building := range game.DeployedBuildings {
+		if building.Active && building.IsDeploying() {
+			building.DeployTicks--
+		}
+	}
+
+	remaining := game.Effects[:0]
+	for _, effect := range game.Effects {
+		if game.GameTime-effect.StartTick < effect.Duration {
+			remaining = append(remaining, effect)
+		}
+	}
+	game.Effects = remaining
+}
+
+// landTroop finishes a deploy: it plays the template's SpawnEffect and
+// knocks back enemy ground troops for units with SpawnPushback
+func landTroop(game *Game, troop *Troop) {
+	template := GetTroopTemplate(troop)
+	if template == nil {
+		return
+	}
+
+	if template.SpawnEffect != "" {
+		game.Effects = append(game.Effects, Effect{
+			Name:      template.SpawnEffect,
+			Position:  troop.Position,
+			Radius:    troop.Size,
+			StartTick: game.GameTime,
+			Duration:  spawnEffectDuration,
+		})
+	}
+
+	if template.SpawnPushback > 0 && template.SpawnPushbackRadius > 0 {
+		applySpawnPushback(game, troop, template)
+	}
+}
+
+// applySpawnPushback knocks enemy ground troops inside SpawnPushbackRadius
+// away from the landing troop by SpawnPushback grid cells, less for heavy ones
+func applySpawnPushback(game *Game, troop *Troop, template *TroopTemplate) {
+	radius := template.SpawnPushbackRadius * game.Grid.CellWidth
+
+	game.Effects = append(game.Effects, Effect{
+		Name:      "spawn_pushback",
+		Position:  troop.Position,
+		Radius:    radius,
+		StartTick: game.GameTime,
+		Duration:  spawnEffectDuration,
+	})
+
+	for i := range game.Troops {
+		other := &game.Troops[i]
+		if !other.Active || other.Team == troop.Team || IsFlyingTroop(other) {
+			continue
+		}
+
+		dx := other.Position.X - troop.Position.X
+		dy := other.Position.Y - troop.Position.Y
+		dist := math.Sqrt(dx*dx + dy*dy)
+		if dist > radius {
+			continue
+		}
+
+		// Troops exactly on top of the lander get pushed toward their own side
+		if dist == 0 {
+			dx, dy, dist = 0, 1, 1
+			if other.Team == 0 {
+				dy = -1
+			}
+		}
+
+		ApplyKnockback(other, dx, dy, template.SpawnPushback, game.Grid)
+		logf("Troop ID=%d pushed back by landing Troop ID=%d\n", other.ID, troop.ID)
+	}
+}
@@ -0,0 +1,218 @@
+// editor.go
+package clashgame
+
+import (
+	"fmt"
+	"image/color"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// EditorBrush is a tile type the editor can paint
+type EditorBrush struct {
+	Name string
+	Tile int
+}
+
+// editorBrushes are selected with the number keys, in this order
+var editorBrushes = []EditorBrush{
+	{"Empty", TileEmpty},
+	{"Red territory", TileTeam1Territory},
+	{"Blue territory", TileTeam2Territory},
+	{"Boundary", TileBoundary},
+	{"Bridge", TileBridge1},
+	{"Bridge edge", TileBridge2},
+	{"Special terrain", TileSpecialTerrain},
+}
+
+// towerSlotNames name the towers the tower tool cycles through per team
+var towerSlotNames = []string{"king", "left princess", "right princess"}
+
+// TilemapEditor is the in-game map editor. While it is active the match is
+// paused and mouse input paints tiles or moves crown towers instead of
+// deploying cards.
+//
+// Keys: E toggles the editor, 1-7 pick a brush, P switches between painting
+// and the tower tool, Tab picks the next tower, V checks connectivity and S
+// saves the tilemap (and the arena file when the arena was loaded from one).
+type TilemapEditor struct {
+	Active    bool
+	Brush     int    // Index into editorBrushes
+	TowerTool bool   // Clicks move the selected tower instead of painting
+	TowerSlot int    // team*3 + index into towerSlotNames
+	Status    string // Last message shown in the editor bar
+}
+
+// NewTilemapEditor creates an inactive editor with the boundary brush
+func NewTilemapEditor() *TilemapEditor {
+	return &TilemapEditor{Brush: 3}
+}
+
+// Update handles the editor's input. The caller holds the game lock.
+func (e *TilemapEditor) Update(g *Game) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		e.Active = !e.Active
+		if e.Active {
+			e.setStatus("Editor on, match paused")
+		} else {
+			e.setStatus("Editor off")
+		}
+	}
+	if !e.Active {
+		return
+	}
+
+	for i := range editorBrushes {
+		if inpututil.IsKeyJustPressed(ebiten.Key1 + ebiten.Key(i)) {
+			e.Brush = i
+			e.TowerTool = false
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		e.TowerTool = !e.TowerTool
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		e.TowerSlot = (e.TowerSlot + 1) % (len(g.Teams) * len(towerSlotNames))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyV) {
+		if err := ValidateConnectivity(g); err != nil {
+			e.setStatus("Invalid: " + err.Error())
+		} else {
+			e.setStatus("Every tower can reach the enemy king")
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		e.save(g)
+	}
+
+	x, y := ebiten.CursorPosition()
+	col, row := g.Grid.PositionToCell(Position{X: float64(x), Y: float64(y)})
+
+	if e.TowerTool {
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			team, slot := e.TowerSlot/len(towerSlotNames), e.TowerSlot%len(towerSlotNames)
+			MoveTower(g, team, slot, col, row)
+		}
+		return
+	}
+
+	// Painting follows the mouse while the button is held; the right
+	// button erases
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		g.Grid.SetTile(col, row, editorBrushes[e.Brush].Tile)
+	} else if ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) {
+		g.Grid.SetTile(col, row, TileEmpty)
+	}
+}
+
+// save writes the tilemap to the file it was loaded from, and the tower
+// layout to the arena file and the tilemap's "Layout" section. Only arenas
+// from an external data directory can be saved; the embedded data is
+// read-only.
+func (e *TilemapEditor) save(g *Game) {
+	if g.Arena.SaveDir == "" {
+		e.setStatus("Save failed: the arena is built in, start with -data to edit files")
+		return
+	}
+	g.Grid.TileMap.SetTowers(g.Arena.Towers)
+	if err := g.Grid.SaveTileMap(filepath.Join(g.Arena.SaveDir, g.Arena.Tilemap)); err != nil {
+		e.setStatus("Save failed: " + err.Error())
+		return
+	}
+	if g.Arena.Path != "" {
+		if err := SaveArena(g.Arena, filepath.Join(g.Arena.SaveDir, g.Arena.Path)); err != nil {
+			e.setStatus("Save failed: " + err.Error())
+			return
+		}
+	}
+	e.setStatus("Saved " + g.Arena.Tilemap)
+}
+
+// setStatus shows a message in the editor bar
+func (e *TilemapEditor) setStatus(message string) {
+	e.Status = message
+	logln("Editor:", message)
+}
+
+// Draw highlights the cell under the cursor, marks the selected tower and
+// shows the editor bar
+func (e *TilemapEditor) Draw(screen *ebiten.Image, g *Game) {
+	if !e.Active {
+		return
+	}
+
+	x, y := ebiten.CursorPosition()
+	col, row := g.Grid.PositionToCell(Position{X: float64(x), Y: float64(y)})
+	ebitenutil.DrawRect(screen,
+		float64(col)*g.Grid.CellWidth, float64(row)*g.Grid.CellHeight,
+		g.Grid.CellWidth, g.Grid.CellHeight,
+		color.RGBA{255, 255, 0, 120})
+
+	team, slot := e.TowerSlot/len(towerSlotNames), e.TowerSlot%len(towerSlotNames)
+	tool := "Paint: " + editorBrushes[e.Brush].Name
+	if e.TowerTool {
+		tool = fmt.Sprintf("Tower: team %d %s", team, towerSlotNames[slot])
+		tower := towerBuilding(g, team, slot)
+		ebitenutil.DrawCircle(screen, tower.Position.X, tower.Position.Y, 6, color.RGBA{255, 255, 0, 200})
+	}
+
+	ebitenutil.DrawRect(screen, 0, 0, float64(screenWidth), 48, color.RGBA{0, 0, 0, 160})
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("EDITOR  %s  cell (%d,%d)", tool, col, row), 10, 4)
+	ebitenutil.DebugPrintAt(screen, "1-7 brush  P towers  Tab next tower  V validate  S save  E exit", 10, 18)
+	ebitenutil.DebugPrintAt(screen, e.Status, 10, 32)
+}
+
+// editing reports whether the editor is open, which pauses the match
+func (g *Game) editing() bool {
+	return g.Editor != nil && g.Editor.Active
+}
+
+// towerBuilding returns a team's tower for a tower slot
+func towerBuilding(g *Game, team, slot int) *Building {
+	if slot == 0 {
+		return &g.Teams[team].KingBuilding.Building
+	}
+	return &g.Teams[team].Buildings[slot-1]
+}
+
+// MoveTower moves a crown tower to a cell, keeping the grid's obstacles and
+// the arena layout in sync
+func MoveTower(g *Game, team, slot, col, row int) {
+	if col < 0 || col >= g.Grid.Columns || row < 0 || row >= g.Grid.Rows {
+		return
+	}
+
+	tower := towerBuilding(g, team, slot)
+	g.Grid.RemoveObstacle(tower)
+	tower.Position = buildingCenter(g.Grid.CellToPosition(col, row), tower.WidthCells, g.Grid)
+	g.Grid.AddObstacle(tower)
+
+	towers := &g.Arena.Towers[team]
+	if slot == 0 {
+		towers.King = CellPos{col, row}
+	} else {
+		towers.Princesses[slot-1] = CellPos{col, row}
+	}
+}
+
+// ValidateConnectivity checks with FindPath that ground troops leaving
+// every crown tower can walk to the enemy king tower
+func ValidateConnectivity(g *Game) error {
+	for team := range g.Teams {
+		enemyKing := g.Teams[EnemyTeam(team)].KingBuilding.Position
+		kingCol, kingRow := g.Grid.PositionToCell(enemyKing)
+		for slot := range towerSlotNames {
+			// Troops can't walk through the tower itself, so start from the
+			// free cell next to it that faces the enemy
+			col, row := g.Grid.PositionToCell(towerBuilding(g, team, slot).Position)
+			col, row, found := g.Grid.nearestFreeCell(col, row, kingCol, kingRow)
+			if !found || FindPath(g, g.Grid.CellToPosition(col, row), enemyKing) == nil {
+				return fmt.Errorf("no path from team %d %s tower to the enemy king", team, towerSlotNames[slot])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// editor_test.go
+package clashgame
+
+import (
+	"encoding/csv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readCSV parses a whole CSV file
+func readCSV(t *testing.T, fsys fs.FS, name string) [][]string {
+	t.Helper()
+	file, err := fsys.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+// TestSaveTileMapKeepsLayout saves the shipped tilemap unchanged and loads
+// it again: the tiles and the tower "Layout" section must survive, and the
+// section rewritten from the arena's towers must match the shipped one
+func TestSaveTileMapKeepsLayout(t *testing.T) {
+	arena := DefaultArena()
+	grid := NewGridSystem(arena)
+	if err := grid.LoadTileMap(arena.data(), arena.Tilemap); err != nil {
+		t.Fatal(err)
+	}
+	if len(grid.TileMap.Trailer) == 0 {
+		t.Fatal("the shipped tilemap has no section after the map rows")
+	}
+	grid.TileMap.SetTowers(arena.Towers)
+
+	dir := t.TempDir()
+	if err := grid.SaveTileMap(filepath.Join(dir, "tilemap.csv")); err != nil {
+		t.Fatal(err)
+	}
+	saved := NewGridSystem(arena)
+	if err := saved.LoadTileMap(os.DirFS(dir), "tilemap.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved.TileMap.Data, grid.TileMap.Data) {
+		t.Error("saved tiles differ from the loaded ones")
+	}
+
+	// The sections after the map come back exactly as shipped
+	want := readCSV(t, arena.data(), arena.Tilemap)[3+arena.Rows:]
+	got := readCSV(t, os.DirFS(dir), "tilemap.csv")[3+arena.Rows:]
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("saved sections after the map differ:\n%v\nwant\n%v", got, want)
+	}
+}
+
+// TestMoveTowerMatchesLoadedPosition moves every crown tower onto the cell
+// it was loaded on; it must stay exactly where it was
+func TestMoveTowerMatchesLoadedPosition(t *testing.T) {
+	game := NewGame(DefaultCatalog(), nil, DefaultGameConfig())
+	for team := range game.Teams {
+		layout := game.Arena.Towers[team]
+		cells := append([]CellPos{layout.King}, layout.Princesses...)
+		for slot, cell := range cells {
+			want := towerBuilding(game, team, slot).Position
+			MoveTower(game, team, slot, cell.Col, cell.Row)
+			if got := towerBuilding(game, team, slot).Position; got != want {
+				t.Errorf("team %d %s tower moved from %v to %v", team, towerSlotNames[slot], want, got)
+			}
+		}
+	}
+}
+
+// TestSaveTileMapMovedTower moves a tower and saves the tilemap the way the
+// editor does: its "Layout" row must follow the arena
+func TestSaveTileMapMovedTower(t *testing.T) {
+	game := NewGame(DefaultCatalog(), nil, DefaultGameConfig())
+	if err := game.Grid.LoadTileMap(game.Arena.data(), game.Arena.Tilemap); err != nil {
+		t.Fatal(err)
+	}
+	MoveTower(game, 1, 0, 20, 55)
+	game.Grid.TileMap.SetTowers(game.Arena.Towers)
+
+	dir := t.TempDir()
+	if err := game.Grid.SaveTileMap(filepath.Join(dir, "tilemap.csv")); err != nil {
+		t.Fatal(err)
+	}
+	records := readCSV(t, os.DirFS(dir), "tilemap.csv")
+	last := records[len(records)-1]
+	if last[2] != "21" || last[3] != "56" {
+		t.Errorf("team 1 king tower saved at (%s,%s), want (21,56)", last[2], last[3])
+	}
+}
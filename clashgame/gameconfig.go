@@ -0,0 +1,68 @@
+// gameconfig.go
+package clashgame
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GameConfig holds the match settings a server may change without
+// recompiling. Game time always advances TickDuration per tick;
+// TickInterval only decides how often StartGameLoop runs a tick, so a
+// shorter interval fast-forwards the match.
+type GameConfig struct {
+	Mode           GameMode      // Match length, elixir curve, overtime and decks
+	TeamSize       int           // Players per team: 1 for 1v1, 2 for 2v2
+	LevelCap       int           // Highest card and king level players bring, 0 for no cap
+	MatchDuration  time.Duration // Wall-clock limit of a live match, on top of the mode's length
+	TickInterval   time.Duration // Wall-clock time between ticks
+	StartingElixir float64
+	MaxElixir      int
+	ElixirGenRate  float64 // Base elixir gained per second of game time, see GameMode.Elixir
+	Seed           int64   // Decides the cards of draft and random decks
+}
+
+// DefaultGameConfig returns the standard match settings
+func DefaultGameConfig() GameConfig {
+	return GameConfig{
+		Mode:           ClassicMode,
+		TeamSize:       1,
+		LevelCap:       TournamentLevelCap,
+		MatchDuration:  10 * time.Minute,
+		TickInterval:   TickDuration,
+		StartingElixir: 4,
+		MaxElixir:      10,
+		ElixirGenRate:  0.1,
+	}
+}
+
+// Validate reports every setting that can't be played with
+func (c GameConfig) Validate() error {
+	var errs []error
+	if err := c.Mode.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.TeamSize < 1 {
+		errs = append(errs, fmt.Errorf("team size must be at least 1, got %d", c.TeamSize))
+	}
+	if c.LevelCap < 0 || c.LevelCap > MaxCardLevel {
+		errs = append(errs, fmt.Errorf("level cap must be between 0 and %d, got %d", MaxCardLevel, c.LevelCap))
+	}
+	if c.MatchDuration <= 0 {
+		errs = append(errs, fmt.Errorf("match duration must be positive, got %v", c.MatchDuration))
+	}
+	if c.TickInterval <= 0 {
+		errs = append(errs, fmt.Errorf("tick interval must be positive, got %v", c.TickInterval))
+	}
+	if c.MaxElixir <= 0 {
+		errs = append(errs, fmt.Errorf("max elixir must be positive, got %d", c.MaxElixir))
+	}
+	if c.StartingElixir < 0 || c.StartingElixir > float64(c.MaxElixir) {
+		errs = append(errs, fmt.Errorf("starting elixir %g must be between 0 and the max elixir %d", c.StartingElixir, c.MaxElixir))
+	}
+	if c.ElixirGenRate < 0 {
+		errs = append(errs, fmt.Errorf("elixir rate can't be negative, got %g", c.ElixirGenRate))
+	}
+	return errors.Join(errs...)
+}
@@ -9,20 +9,27 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
-func StartGameLoop(game *Game, tilemapPath string) {
-    fmt.Println("starting game loop...")
+// TickDuration is the length of one simulation step
+const TickDuration = 40 * time.Millisecond
+
+// TicksPerSecond is how many simulation steps run per second of game time
+const TicksPerSecond = int(time.Second / TickDuration)
+
+// StartGameLoop runs the match in real time: a tick every TickInterval of
+// the game's config until the mode ends the match or the match duration has
+// passed
+func StartGameLoop(game *Game) {
+    logln("starting game loop...")
     
-    game.Running = true
-    game.Ticker = time.NewTicker(time.Millisecond * 40)
-    gameTimer := time.NewTimer(time.Duration(DURATION) * time.Minute)
+    game.setRunning(true)
+    game.Ticker = time.NewTicker(game.Config.TickInterval)
+    gameTimer := time.NewTimer(game.Config.MatchDuration)
     broadcastStateTicker := time.NewTicker(time.Millisecond * 33)
-    elixirTicker := time.NewTicker(time.Second)
     
     go func() {
         defer game.Ticker.Stop()
         defer gameTimer.Stop()
         defer broadcastStateTicker.Stop()
-        defer elixirTicker.Stop()
         
         for {
             if !game.IsActive() {
@@ -31,26 +38,15 @@ func StartGameLoop(game *Game, tilemapPath string) {
             
             select {
             case <-game.Ticker.C:
-                game.GameTime++
-                
-                // Process these updates in an improved order:
-                // 1. Update projectiles first to ensure they hit targets before they move
-                UpdateProjectiles(game)
-                
-                // 2. Now update troops with the old projectiles cleared
-                UpdateTroopMovement(game)
-                
-                // 3. Clear any invalid attack states
-                ClearInvalidAttackStates(game)
+                game.Tick()
                 
             case <-broadcastStateTicker.C:
-                // broadcastStateToClients(game)
-                // broadcastStateToSpectators(game)
-            case <-elixirTicker.C:
-                UpdateElixir(game)
+                if game.Server != nil {
+                    game.Server.Broadcast()
+                }
             case <-gameTimer.C:
-                fmt.Println("Game over: Time's up!")
-                game.Running = false
+                logln("Game over: Time's up!")
+                game.setRunning(false)
             case <-game.StopChannel:
                 return
             }
@@ -58,8 +54,80 @@ func StartGameLoop(game *Game, tilemapPath string) {
     }()
 }
 
+// Tick advances the simulation by one step. It holds the game lock for the
+// whole step so Ebiten never observes a half-updated state.
+func (game *Game) Tick() {
+    game.mu.Lock()
+    defer game.mu.Unlock()
+    
+    // Swap in reloaded data before anything reads the catalog
+    game.applyQueuedCatalog()
+    
+    // The match stands still while the map editor is open, and for good
+    // once it has ended
+    if game.editing() || game.Phase == PhaseEnded {
+        return
+    }
+    
+    game.GameTime++
+    
+    // Elixir accrues in game time, so headless matches and replays get the
+    // same amount as live ones
+    if game.GameTime%TicksPerSecond == 0 {
+        UpdateElixir(game)
+    }
+    
+    // Apply queued player commands at the tick boundary
+    ProcessCommands(game)
+    
+    // Count down deploy timers so troops landing this tick can act right away
+    UpdateDeployingTroops(game)
+    
+    // Expire troops whose LifeTime ran out
+    UpdateTroopLifetimes(game)
+    
+    // Process these updates in an improved order:
+    // 1. Pick or keep targets so movement and combat agree on them
+    UpdateTroopTargets(game)
+    
+    // 2. Update projectiles to ensure they hit targets before they move
+    UpdateProjectiles(game)
+    
+    // 3. Now update troops with the old projectiles cleared
+    UpdateTroopMovement(game)
+    
+    // 4. Push overlapping units apart by mass
+    ResolveCollisions(game)
+    
+    // 5. Attack locked targets that are in range
+    UpdateCombat(game)
+    
+    // 6. Run death damage and death spawns of everything that died
+    ProcessDeaths(game)
+    
+    // 7. Expire buildings and free the cells of destroyed ones so paths
+    //    get replanned
+    UpdateBuildings(game)
+    
+    // 8. Clear any invalid attack states
+    ClearInvalidAttackStates(game)
+    
+    // 9. Apply the mode's win conditions and overtime
+    UpdateMatchResult(game)
+    
+    // 10. Let bots react to the finished state; their commands apply next tick
+    UpdateBots(game)
+    
+    // 11. Remember where troops ended up so frames drawn before the next
+    //     tick can interpolate
+    RecordPositionHistory(game)
+}
+
 // DrawGame draws the game state
 func DrawGame(screen *ebiten.Image, game *Game) {
+    game.mu.Lock()
+    defer game.mu.Unlock()
+    
     // Draw the grid first
     game.Grid.Draw(screen)
     
@@ -76,9 +144,11 @@ func DrawGame(screen *ebiten.Image, game *Game) {
         }
     }
     
-    // Draw troops
+    // Draw troops between their last two sim positions
+    alpha := game.renderAlpha()
     for _, troop := range game.Troops {
         if troop.Active {
+            troop.Position = troop.RenderPosition(alpha)
             game.TroopDrawer.DrawTroop(screen, &troop)
         }
     }
@@ -0,0 +1,86 @@
+// gameloop_test.go
+package clashgame
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// deployCell finds a cell where the player may deploy a card they can play
+func deployCell(t *testing.T, game *Game, player int) Command {
+	t.Helper()
+	card := "Knight"
+	if game.Players[player].HasHand() {
+		card = game.Players[player].Hand[0]
+	}
+	for row := 0; row < game.Grid.Rows; row++ {
+		for col := 0; col < game.Grid.Columns; col++ {
+			cmd := Command{Type: CommandDeployCard, Player: player, Card: card, Col: col, Row: row}
+			if ValidateCommand(game, cmd) == nil {
+				return cmd
+			}
+		}
+	}
+	t.Fatalf("player %d has nowhere to deploy %s", player, card)
+	return Command{}
+}
+
+// TestConcurrentCommandsTickAndDraw submits commands, ticks and draws from
+// separate goroutines the way the input handlers, StartGameLoop and Ebiten
+// do. Run it with -race; it fails there if any path skips the game lock.
+func TestConcurrentCommandsTickAndDraw(t *testing.T) {
+	game := NewGame(DefaultCatalog(), nil, DefaultGameConfig())
+	game.TroopDrawer = NewEnhancedTroopDrawer(game)
+	game.Running = true
+	for i := range game.Players {
+		game.Players[i].Elixir = float64(game.Players[i].ElixirMax)
+	}
+	deploys := []Command{deployCell(t, game, 0), deployCell(t, game, 1)}
+
+	const ticks = 200
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < ticks; i++ {
+			game.Tick()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			game.SubmitCommand(deploys[i%len(deploys)])
+			game.SubmitCommand(Command{Type: CommandEmote, Player: i % len(deploys), Emote: "Thumbs up"})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		screen := ebiten.NewImage(screenWidth, screenHeight)
+		for i := 0; i < 50; i++ {
+			DrawGame(screen, game)
+			game.Draw(screen)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			game.Snapshot()
+			game.IsActive()
+		}
+	}()
+	wg.Wait()
+
+	// Commands submitted after the last tick are still queued
+	game.Tick()
+	if pending := game.Commands.Len(); pending != 0 {
+		t.Errorf("%d commands were never processed", pending)
+	}
+	if len(game.CommandLog) == 0 {
+		t.Error("no command was applied")
+	}
+	if game.GameTime != ticks+1 {
+		t.Errorf("game time is %d after %d ticks", game.GameTime, ticks+1)
+	}
+}
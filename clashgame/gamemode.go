@@ -0,0 +1,281 @@
+// gamemode.go
+package clashgame
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// DeckSize is how many cards a dealt deck holds
+const DeckSize = 8
+
+// DeckSource decides where the players' decks come from
+type DeckSource int
+
+const (
+	DeckOwn    DeckSource = iota // The deck in each player's profile
+	DeckDraft                    // Drafted from pairs of cards; the card not picked goes to the opponent
+	DeckRandom                   // Random cards, different for each player
+)
+
+// String returns the deck source's name
+func (d DeckSource) String() string {
+	switch d {
+	case DeckOwn:
+		return "own"
+	case DeckDraft:
+		return "draft"
+	case DeckRandom:
+		return "random"
+	default:
+		return fmt.Sprintf("decksource(%d)", int(d))
+	}
+}
+
+// MatchPhase is how far a match has progressed
+type MatchPhase int
+
+const (
+	PhaseRegulation MatchPhase = iota
+	PhaseOvertime              // Regulation ended level; the next crown wins
+	PhaseEnded                 // Decided, or a draw when Winner is NoWinner
+)
+
+// String returns the phase's name
+func (p MatchPhase) String() string {
+	switch p {
+	case PhaseRegulation:
+		return "regulation"
+	case PhaseOvertime:
+		return "overtime"
+	case PhaseEnded:
+		return "ended"
+	default:
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+}
+
+// ElixirPhase multiplies the base elixir rate from a point in the match on
+type ElixirPhase struct {
+	From       time.Duration // Game time the phase starts at
+	Multiplier float64
+}
+
+// GameMode is the rule set of a match. Durations are game time, so a mode
+// plays out the same in a live match, a fast-forwarded one and a headless
+// simulation.
+//
+// A king tower falling always ends the match. When regulation ends, the
+// team with more crowns wins; on a level score the match goes to overtime,
+// where the next crown wins. If time runs out level, the team whose weakest
+// tower has more health wins with TowerHealthTiebreak, otherwise it's a draw.
+type GameMode struct {
+	Name                string
+	Regulation          time.Duration
+	Overtime            time.Duration // 0 ends level matches after regulation
+	Elixir              []ElixirPhase // Sorted by From; before the first phase the rate is 1x
+	SuddenDeath         bool          // The first crown wins, in regulation too
+	TowerHealthTiebreak bool
+	Decks               DeckSource
+}
+
+// The modes a match can be played in
+var (
+	ClassicMode = GameMode{
+		Name:       "classic",
+		Regulation: 3 * time.Minute,
+		Overtime:   2 * time.Minute,
+		Elixir: []ElixirPhase{
+			{From: 2 * time.Minute, Multiplier: 2},
+			{From: 4 * time.Minute, Multiplier: 3},
+		},
+		TowerHealthTiebreak: true,
+	}
+	DoubleElixirMode = GameMode{
+		Name:       "double",
+		Regulation: 3 * time.Minute,
+		Overtime:   2 * time.Minute,
+		Elixir: []ElixirPhase{
+			{From: 0, Multiplier: 2},
+			{From: 2 * time.Minute, Multiplier: 3},
+		},
+		TowerHealthTiebreak: true,
+	}
+	TripleElixirMode = GameMode{
+		Name:                "triple",
+		Regulation:          3 * time.Minute,
+		Overtime:            2 * time.Minute,
+		Elixir:              []ElixirPhase{{From: 0, Multiplier: 3}},
+		TowerHealthTiebreak: true,
+	}
+	SuddenDeathMode = GameMode{
+		Name:       "sudden-death",
+		Regulation: 3 * time.Minute,
+		Elixir: []ElixirPhase{
+			{From: 0, Multiplier: 2},
+			{From: 2 * time.Minute, Multiplier: 3},
+		},
+		SuddenDeath:         true,
+		TowerHealthTiebreak: true,
+	}
+	DraftMode = GameMode{
+		Name:       "draft",
+		Regulation: 3 * time.Minute,
+		Overtime:   2 * time.Minute,
+		Elixir: []ElixirPhase{
+			{From: 2 * time.Minute, Multiplier: 2},
+			{From: 4 * time.Minute, Multiplier: 3},
+		},
+		TowerHealthTiebreak: true,
+		Decks:               DeckDraft,
+	}
+)
+
+// GameModes lists the built-in modes
+func GameModes() []GameMode {
+	return []GameMode{ClassicMode, DoubleElixirMode, TripleElixirMode, SuddenDeathMode, DraftMode}
+}
+
+// GameModeNames lists the names ParseGameMode accepts
+func GameModeNames() []string {
+	var names []string
+	for _, mode := range GameModes() {
+		names = append(names, mode.Name)
+	}
+	return names
+}
+
+// ParseGameMode looks up a built-in mode by name, like "sudden-death"
+func ParseGameMode(name string) (GameMode, error) {
+	for _, mode := range GameModes() {
+		if strings.EqualFold(name, mode.Name) {
+			return mode, nil
+		}
+	}
+	return GameMode{}, fmt.Errorf("unknown game mode %q, want one of %s", name, strings.Join(GameModeNames(), ", "))
+}
+
+// Validate reports every rule that can't be played with
+func (m GameMode) Validate() error {
+	var errs []error
+	if m.Regulation <= 0 {
+		errs = append(errs, fmt.Errorf("mode %s: regulation must be positive, got %v", m.Name, m.Regulation))
+	}
+	if m.Overtime < 0 {
+		errs = append(errs, fmt.Errorf("mode %s: overtime can't be negative, got %v", m.Name, m.Overtime))
+	}
+	for i, phase := range m.Elixir {
+		if phase.Multiplier < 0 {
+			errs = append(errs, fmt.Errorf("mode %s: elixir multiplier can't be negative, got %g", m.Name, phase.Multiplier))
+		}
+		if i > 0 && phase.From < m.Elixir[i-1].From {
+			errs = append(errs, fmt.Errorf("mode %s: elixir phases must be sorted by start", m.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RegulationTicks is the length of regulation in ticks
+func (m GameMode) RegulationTicks() int {
+	return SecondsToTicks(m.Regulation.Seconds())
+}
+
+// LengthTicks is the longest the match can last in ticks, overtime included
+func (m GameMode) LengthTicks() int {
+	return SecondsToTicks((m.Regulation + m.Overtime).Seconds())
+}
+
+// ElixirMultiplier returns the factor on the base elixir rate at a tick
+func (m GameMode) ElixirMultiplier(tick int) float64 {
+	multiplier := 1.0
+	for _, phase := range m.Elixir {
+		if tick < SecondsToTicks(phase.From.Seconds()) {
+			break
+		}
+		multiplier = phase.Multiplier
+	}
+	return multiplier
+}
+
+// dealDecks replaces the profile decks when the mode doesn't play the
+// players' own. Dealt cards play at the tournament level. The seed decides
+// the cards, so the same seed deals the same decks. Drafts are held between
+// opponents: player 0 with player 1, player 2 with player 3 and so on.
+func (m GameMode) dealDecks(catalog *Catalog, seed int64, profiles []PlayerProfile) {
+	if m.Decks == DeckOwn {
+		return
+	}
+
+	// Every card with a cost is fair game
+	var pool []string
+	for _, name := range catalog.CardNames() {
+		if card, _ := catalog.Card(name); card.ElixirCost > 0 {
+			pool = append(pool, name)
+		}
+	}
+	random := rand.New(rand.NewSource(seed))
+
+	decks := make([][]string, len(profiles))
+	switch m.Decks {
+	case DeckDraft:
+		for first := 0; first+1 < len(decks); first += 2 {
+			drafted := draftDecks(pool, random)
+			decks[first], decks[first+1] = drafted[0], drafted[1]
+		}
+	case DeckRandom:
+		for i := range decks {
+			decks[i] = randomDeck(pool, random)
+		}
+	}
+
+	for i, deck := range decks {
+		profiles[i].Deck = nil
+		for _, name := range deck {
+			profiles[i].Deck = append(profiles[i].Deck, DeckCard{Name: name, Level: TournamentLevelCap})
+		}
+	}
+}
+
+// randomDeck draws DeckSize different cards from the pool
+func randomDeck(pool []string, random *rand.Rand) []string {
+	var deck []string
+	for _, i := range random.Perm(len(pool)) {
+		if len(deck) == DeckSize {
+			break
+		}
+		deck = append(deck, pool[i])
+	}
+	return deck
+}
+
+// draftDecks offers DeckSize pairs of different cards. The teams take
+// turns picking first; the picker keeps one card and the other goes to the
+// opponent. There is no pick screen yet, so picks are random.
+func draftDecks(pool []string, random *rand.Rand) [2][]string {
+	var decks [2][]string
+	order := random.Perm(len(pool))
+	for pair := 0; pair < DeckSize && 2*pair+1 < len(order); pair++ {
+		options := [2]string{pool[order[2*pair]], pool[order[2*pair+1]]}
+		picker := pair % 2
+		pick := random.Intn(2)
+		decks[picker] = append(decks[picker], options[pick])
+		decks[1-picker] = append(decks[1-picker], options[1-pick])
+	}
+	return decks
+}
+
+// towerHealth is the health of a team's weakest standing crown tower, the
+// tiebreaker when a match ends level
+func towerHealth(game *Game, team int) int {
+	towers := &game.Teams[team]
+	weakest := towers.KingBuilding.Health
+	for _, tower := range towers.Buildings {
+		if tower.Active && tower.Health < weakest {
+			weakest = tower.Health
+		}
+	}
+	return weakest
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"image/color"
 	"io"
+	"io/fs"
 	"math"
 	"os"
 	"strconv"
@@ -36,49 +37,44 @@ const (
 
 // TileMap stores the tile data from CSV
 type TileMap struct {
-	Data [][]int
+	Data    [][]int
+	Trailer [][]string // Sections after the map rows, like the tower "Layout", kept for SaveTileMap
 }
 
-// NewGridSystem creates a grid with default cell types
-func NewGridSystem() *GridSystem {
+// NewGridSystem creates the grid for an arena, marking its river and bridges
+func NewGridSystem(arena *Arena) *GridSystem {
 	// Create the grid
 	grid := &GridSystem{
-		CellWidth:  float64(screenWidth) / GridColumns,
-		CellHeight: float64(screenHeight) / GridRows,
+		CellWidth:  float64(screenWidth) / float64(arena.Columns),
+		CellHeight: float64(screenHeight) / float64(arena.Rows),
 		ShowGrid:   false,
-		CellTypes:  make([][]int, GridRows),
+		CellTypes:  make([][]int, arena.Rows),
+		Occupied:   make([][]int, arena.Rows),
+		Columns:    arena.Columns,
+		Rows:       arena.Rows,
+		RiverStartRow: arena.RiverStartRow,
+		RiverEndRow:   arena.RiverEndRow,
+		Bridges:    append([]Bridge(nil), arena.Bridges...),
 	}
 	
 	// Initialize all cells as ground
 	for i := range grid.CellTypes {
-		grid.CellTypes[i] = make([]int, GridColumns)
+		grid.CellTypes[i] = make([]int, arena.Columns)
+		grid.Occupied[i] = make([]int, arena.Columns)
 		// Default to ground
 		for j := range grid.CellTypes[i] {
 			grid.CellTypes[i][j] = CellTypeGround
 		}
 	}
 	
-	// Set up the Clash Royale style map with water and bridges
-	// Water in the middle section
-	waterStartRow := 30
-	waterEndRow := 33
-	
-	// Bridge positions
-	leftBridgeCol := 5
-	rightBridgeCol := 27
-	bridgeWidth := 4
-	
-	// Create water and bridges
-	for row := waterStartRow; row <= waterEndRow; row++ {
-		for col := 0; col < GridColumns; col++ {
-			// Check if this cell is part of a bridge
-			isLeftBridge := col >= leftBridgeCol && col < leftBridgeCol+bridgeWidth
-			isRightBridge := col >= rightBridgeCol && col < rightBridgeCol+bridgeWidth
-			
-			if isLeftBridge || isRightBridge {
+	// Create water and bridges across the river rows
+	for row := arena.RiverStartRow; row <= arena.RiverEndRow; row++ {
+		for col := 0; col < arena.Columns; col++ {
+			grid.CellTypes[row][col] = CellTypeWater
+		}
+		for _, bridge := range arena.Bridges {
+			for col := bridge.Col; col < bridge.Col+bridge.Width; col++ {
 				grid.CellTypes[row][col] = CellTypeBridge
-			} else {
-				grid.CellTypes[row][col] = CellTypeWater
 			}
 		}
 	}
@@ -88,7 +84,7 @@ func NewGridSystem() *GridSystem {
 
 // GetCellType returns the type of cell at the given coordinates
 func (g *GridSystem) GetCellType(col, row int) int {
-	if row < 0 || row >= GridRows || col < 0 || col >= GridColumns {
+	if row < 0 || row >= g.Rows || col < 0 || col >= g.Columns {
 		return CellTypeGround // Default for out-of-bounds
 	}
 	return g.CellTypes[row][col]
@@ -96,7 +92,7 @@ func (g *GridSystem) GetCellType(col, row int) int {
 
 // SetCellType changes the type of a cell
 func (g *GridSystem) SetCellType(col, row, cellType int) {
-	if row >= 0 && row < GridRows && col >= 0 && col < GridColumns {
+	if row >= 0 && row < g.Rows && col >= 0 && col < g.Columns {
 		g.CellTypes[row][col] = cellType
 	}
 }
@@ -120,8 +116,8 @@ func (g *GridSystem) PositionToCell(pos Position) (int, int) {
 func (g *GridSystem) Draw(screen *ebiten.Image) {
 	// Draw tiles from CSV first if available
 	if g.TileMap != nil {
-		for row := 0; row < GridRows; row++ {
-			for col := 0; col < GridColumns; col++ {
+		for row := 0; row < g.Rows; row++ {
+			for col := 0; col < g.Columns; col++ {
 				tileType := g.TileMap.Data[row][col]
 				
 				// Calculate tile position
@@ -163,8 +159,8 @@ func (g *GridSystem) Draw(screen *ebiten.Image) {
 	}
 	
 	// Draw cell backgrounds based on type
-	for row := 0; row < GridRows; row++ {
-		for col := 0; col < GridColumns; col++ {
+	for row := 0; row < g.Rows; row++ {
+		for col := 0; col < g.Columns; col++ {
 			cellType := g.GetCellType(col, row)
 			
 			// Only draw water and bridge cells with special colors
@@ -194,7 +190,7 @@ func (g *GridSystem) Draw(screen *ebiten.Image) {
 	gridColor := color.RGBA{100, 100, 255, 255}
 	
 	// Draw vertical lines (columns)
-	for i := 0; i <= GridColumns; i++ {
+	for i := 0; i <= g.Columns; i++ {
 		x := float64(i) * g.CellWidth
 		ebitenutil.DrawLine(
 			screen,
@@ -204,7 +200,7 @@ func (g *GridSystem) Draw(screen *ebiten.Image) {
 		)
 		
 		// Draw column numbers at the top
-		if i < GridColumns {
+		if i < g.Columns {
 			ebitenutil.DebugPrintAt(
 				screen,
 				fmt.Sprintf("%d", i),
@@ -214,7 +210,7 @@ func (g *GridSystem) Draw(screen *ebiten.Image) {
 	}
 	
 	// Draw horizontal lines (rows)
-	for i := 0; i <= GridRows; i++ {
+	for i := 0; i <= g.Rows; i++ {
 		y := float64(i) * g.CellHeight
 		ebitenutil.DrawLine(
 			screen,
@@ -224,7 +220,7 @@ func (g *GridSystem) Draw(screen *ebiten.Image) {
 		)
 		
 		// Draw row numbers on the left
-		if i < GridRows {
+		if i < g.Rows {
 			ebitenutil.DebugPrintAt(
 				screen,
 				fmt.Sprintf("%d", i),
@@ -238,15 +234,15 @@ func (g *GridSystem) ToggleGrid() {
 	g.ShowGrid = !g.ShowGrid
 }
 
-// LoadTileMap loads the tilemap from a CSV file
-func (g *GridSystem) LoadTileMap(filepath string) error {
+// LoadTileMap loads the tilemap from a CSV file in a data FS
+func (g *GridSystem) LoadTileMap(fsys fs.FS, name string) error {
 	// Initialize the map
 	g.TileMap = &TileMap{
-		Data: make([][]int, GridRows),
+		Data: make([][]int, g.Rows),
 	}
 	
 	// Open and read the CSV file
-	file, err := os.Open(filepath)
+	file, err := fsys.Open(name)
 	if err != nil {
 		return fmt.Errorf("failed to open tilemap: %v", err)
 	}
@@ -270,11 +266,11 @@ func (g *GridSystem) LoadTileMap(filepath string) error {
 
 	// Initialize all rows
 	for i := range g.TileMap.Data {
-		g.TileMap.Data[i] = make([]int, GridColumns)
+		g.TileMap.Data[i] = make([]int, g.Columns)
 	}
 
 	// Read each row
-	for row := 0; row < GridRows; row++ {
+	for row := 0; row < g.Rows; row++ {
 		record, err := reader.Read()
 		if err != nil {
 			if err == io.EOF {
@@ -284,7 +280,7 @@ func (g *GridSystem) LoadTileMap(filepath string) error {
 		}
 
 		// Parse each column, starting from index 1 (skip first column)
-		for col := 0; col < GridColumns; col++ {
+		for col := 0; col < g.Columns; col++ {
 			if col+1 >= len(record) || record[col+1] == "" {
 				g.TileMap.Data[row][col] = TileEmpty
 				continue
@@ -292,7 +288,7 @@ func (g *GridSystem) LoadTileMap(filepath string) error {
 
 			value, err := strconv.Atoi(record[col+1])
 			if err != nil {
-				fmt.Printf("Warning: Invalid tile value at row %d, col %d: %s\n", row, col, record[col+1])
+				logf("Warning: Invalid tile value at row %d, col %d: %s\n", row, col, record[col+1])
 				g.TileMap.Data[row][col] = TileEmpty
 				continue
 			}
@@ -300,13 +296,139 @@ func (g *GridSystem) LoadTileMap(filepath string) error {
 		}
 	}
 
-	fmt.Printf("Successfully loaded tilemap with %d rows\n", len(g.TileMap.Data))
+	// Keep whatever follows the map rows so saving doesn't drop it
+	reader.FieldsPerRecord = -1
+	g.TileMap.Trailer, err = reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("error reading the sections after the map: %v", err)
+	}
+
+	logf("Successfully loaded tilemap with %d rows\n", len(g.TileMap.Data))
+	return nil
+}
+
+// SetTowers rewrites the tower "Layout" section of the trailer from an
+// arena's crown towers, team 0 first and princesses left lane first. Cells
+// are written counted from 1. The section is added if the tilemap had none.
+func (m *TileMap) SetTowers(towers [2]TowerLayout) {
+	if m == nil {
+		return
+	}
+	width := 4
+	if len(m.Data) > 0 {
+		width = len(m.Data[0]) + 1
+	}
+	row := func(fields ...string) []string {
+		return append(fields, make([]string, max(0, width-len(fields)))...)
+	}
+	cell := func(pos CellPos) []string {
+		return row("", "", strconv.Itoa(pos.Col+1), strconv.Itoa(pos.Row+1))
+	}
+
+	layout := [][]string{row("Layout"), row("", "PrincessTower"), row("", "", "x", "y"), row("", "", "int", "int")}
+	for _, team := range towers {
+		for _, princess := range team.Princesses {
+			layout = append(layout, cell(princess))
+		}
+	}
+	layout = append(layout, row("", "KingTower"), row("", "", "x", "y"), row("", "", "int", "int"))
+	for _, team := range towers {
+		layout = append(layout, cell(team.King))
+	}
+
+	// Replace the old section up to the next one, keeping the others
+	start, end := len(m.Trailer), len(m.Trailer)
+	for i, record := range m.Trailer {
+		if len(record) == 0 || record[0] == "" {
+			continue
+		}
+		if record[0] == "Layout" {
+			start = i
+		} else if i > start {
+			end = i
+			break
+		}
+	}
+	trailer := append([][]string(nil), m.Trailer[:start]...)
+	trailer = append(trailer, layout...)
+	m.Trailer = append(trailer, m.Trailer[end:]...)
+}
+
+// SaveTileMap writes the tilemap back to a CSV file in the format
+// LoadTileMap reads: a "Map" row, the x and int header rows, then one row
+// per grid row with an empty first column. Empty tiles are left blank. The
+// sections that followed the map rows when it was loaded are written back
+// as they are; the editor updates the tower layout first, see SetTowers.
+func (g *GridSystem) SaveTileMap(filepath string) error {
+	if g.TileMap == nil {
+		return fmt.Errorf("no tilemap loaded")
+	}
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to create tilemap: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	header := make([]string, g.Columns+1)
+	header[0] = "Map"
+	xRow := make([]string, g.Columns+1)
+	typeRow := make([]string, g.Columns+1)
+	for col := 1; col <= g.Columns; col++ {
+		xRow[col] = "x"
+		typeRow[col] = "int"
+	}
+	if err := writer.WriteAll([][]string{header, xRow, typeRow}); err != nil {
+		return fmt.Errorf("failed to write tilemap header: %v", err)
+	}
+
+	for row := 0; row < g.Rows; row++ {
+		record := make([]string, g.Columns+1)
+		for col := 0; col < g.Columns; col++ {
+			if tile := g.TileMap.Data[row][col]; tile != TileEmpty {
+				record[col+1] = strconv.Itoa(tile)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write tilemap row %d: %v", row, err)
+		}
+	}
+	if err := writer.WriteAll(g.TileMap.Trailer); err != nil {
+		return fmt.Errorf("failed to write the sections after the map: %v", err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write tilemap: %v", err)
+	}
+	logf("Saved tilemap with %d rows to %s\n", g.Rows, filepath)
 	return nil
 }
 
-// IsWalkableTile checks if a tile can be walked on
+// SetTile changes one tile of the tilemap. Walkability may change, so cached
+// paths are invalidated.
+func (g *GridSystem) SetTile(col, row, tileType int) {
+	if g.TileMap == nil || row < 0 || row >= g.Rows || col < 0 || col >= g.Columns {
+		return
+	}
+	if g.TileMap.Data[row][col] == tileType {
+		return
+	}
+	g.TileMap.Data[row][col] = tileType
+	g.ObstacleVersion++
+}
+
+// IsWalkableTile checks if a tile can be walked on and isn't covered by a
+// building
 func (g *GridSystem) IsWalkableTile(col, row int) bool {
-	if row < 0 || row >= GridRows || col < 0 || col >= GridColumns {
+	return g.IsWalkableTerrain(col, row) && !g.IsOccupied(col, row)
+}
+
+// IsWalkableTerrain checks the tilemap only, ignoring buildings
+func (g *GridSystem) IsWalkableTerrain(col, row int) bool {
+	if row < 0 || row >= g.Rows || col < 0 || col >= g.Columns {
 		return false
 	}
 
@@ -324,8 +446,100 @@ func (g *GridSystem) IsWalkableTile(col, row int) bool {
 
 // GetTileType returns the type of tile at the given position
 func (g *GridSystem) GetTileType(col, row int) int {
-	if row < 0 || row >= GridRows || col < 0 || col >= GridColumns {
+	if row < 0 || row >= g.Rows || col < 0 || col >= g.Columns {
 		return TileBoundary
 	}
 	return g.TileMap.Data[row][col]
-}
\ No newline at end of file
+}
+// IsOccupied reports whether a building covers the cell
+func (g *GridSystem) IsOccupied(col, row int) bool {
+	if row < 0 || row >= g.Rows || col < 0 || col >= g.Columns {
+		return false
+	}
+	return g.Occupied[row][col] > 0
+}
+
+// BuildingFootprint returns the inclusive cell range a building covers
+func (g *GridSystem) BuildingFootprint(b *Building) (minCol, minRow, maxCol, maxRow int) {
+	width, height := b.GetPixelDimensions(g)
+	// Shrink slightly so an edge lying exactly on a cell border stays outside
+	minCol, minRow = g.PositionToCell(Position{X: b.Position.X - width/2 + 0.01, Y: b.Position.Y - height/2 + 0.01})
+	maxCol, maxRow = g.PositionToCell(Position{X: b.Position.X + width/2 - 0.01, Y: b.Position.Y + height/2 - 0.01})
+	return
+}
+
+// AddObstacle marks a building's footprint as blocked
+func (g *GridSystem) AddObstacle(b *Building) {
+	if b.Obstacle {
+		return
+	}
+	g.markFootprint(b, 1)
+	b.Obstacle = true
+}
+
+// RemoveObstacle frees a building's footprint again
+func (g *GridSystem) RemoveObstacle(b *Building) {
+	if !b.Obstacle {
+		return
+	}
+	g.markFootprint(b, -1)
+	b.Obstacle = false
+}
+
+// markFootprint adds delta to every cell under a building and invalidates
+// cached paths
+func (g *GridSystem) markFootprint(b *Building, delta int) {
+	minCol, minRow, maxCol, maxRow := g.BuildingFootprint(b)
+	for row := minRow; row <= maxRow; row++ {
+		for col := minCol; col <= maxCol; col++ {
+			if row >= 0 && row < g.Rows && col >= 0 && col < g.Columns {
+				g.Occupied[row][col] += delta
+			}
+		}
+	}
+	g.ObstacleVersion++
+}
+
+// IsFootprintFree checks that a building of the given size centered on a
+// position would only cover free, walkable land
+func (g *GridSystem) IsFootprintFree(b *Building) bool {
+	minCol, minRow, maxCol, maxRow := g.BuildingFootprint(b)
+	for row := minRow; row <= maxRow; row++ {
+		for col := minCol; col <= maxCol; col++ {
+			if !g.IsWalkableTile(col, row) || g.GetCellType(col, row) == CellTypeWater {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// nearestFreeCell finds the walkable cell closest to (col, row), preferring
+// cells nearer to (fromCol, fromRow) on ties. It is used to path next to a
+// building whose own cells are blocked.
+func (g *GridSystem) nearestFreeCell(col, row, fromCol, fromRow int) (int, int, bool) {
+	maxRadius := g.Rows
+	for radius := 1; radius < maxRadius; radius++ {
+		bestCol, bestRow := -1, -1
+		bestDist := math.MaxFloat64
+		for r := row - radius; r <= row+radius; r++ {
+			for c := col - radius; c <= col+radius; c++ {
+				// Only look at the ring at this radius
+				if r != row-radius && r != row+radius && c != col-radius && c != col+radius {
+					continue
+				}
+				if !g.IsWalkableTile(c, r) {
+					continue
+				}
+				dist := math.Hypot(float64(c-fromCol), float64(r-fromRow))
+				if dist < bestDist {
+					bestCol, bestRow, bestDist = c, r, dist
+				}
+			}
+		}
+		if bestCol >= 0 {
+			return bestCol, bestRow, true
+		}
+	}
+	return col, row, false
+}
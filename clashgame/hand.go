@@ -0,0 +1,63 @@
+// hand.go
+package clashgame
+
+// HandSize is how many cards a player can choose from at once
+const HandSize = 4
+
+// dealHand deals the opening hand in deck order; the rest of the deck waits
+// in the card queue
+func (p *Player) dealHand() {
+	p.Hand = nil
+	p.CardQueue = nil
+	for i, card := range p.Deck {
+		if i < HandSize {
+			p.Hand = append(p.Hand, card.Name)
+		} else {
+			p.CardQueue = append(p.CardQueue, card.Name)
+		}
+	}
+}
+
+// HasHand reports whether the player plays from a hand. Players without a
+// deck may play any card, like the debug card bar.
+func (p *Player) HasHand() bool {
+	return len(p.Deck) > 0
+}
+
+// InHand checks if a card can be played right now
+func (p *Player) InHand(name string) bool {
+	for _, card := range p.Hand {
+		if card == name {
+			return true
+		}
+	}
+	return false
+}
+
+// playCard cycles a played card to the back of the queue and draws the next
+// card into its slot
+func (p *Player) playCard(name string) {
+	for i, card := range p.Hand {
+		if card != name {
+			continue
+		}
+		p.CardQueue = append(p.CardQueue, name)
+		p.Hand[i] = p.CardQueue[0]
+		p.CardQueue = p.CardQueue[1:]
+		return
+	}
+}
+
+// localHand is the hand and next card of the local player, player 0, for the
+// card bar. The hand is nil when the player may play any card.
+func (g *Game) localHand() ([]string, string) {
+	if len(g.Players) == 0 || !g.Players[0].HasHand() {
+		return nil, ""
+	}
+	player := &g.Players[0]
+	next := ""
+	if len(player.CardQueue) > 0 {
+		next = player.CardQueue[0]
+	}
+	return player.Hand, next
+}
@@ -0,0 +1,123 @@
+// interpolation.go
+package clashgame
+
+import (
+	"math"
+	"time"
+)
+
+// Reset fills the history with one position, for a troop that just appeared
+// and has nowhere to be drawn coming from
+func (h *TroopPositionHistory) Reset(position Position) {
+	h.Positions = make([]Position, PositionHistoryLength)
+	h.Index = 0
+	for i := range h.Positions {
+		h.Positions[i] = position
+	}
+}
+
+// Record adds the position a troop ended a sim step at
+func (h *TroopPositionHistory) Record(position Position) {
+	if len(h.Positions) == 0 {
+		h.Reset(position)
+		return
+	}
+	h.Positions[h.Index] = position
+	h.Index = (h.Index + 1) % len(h.Positions)
+}
+
+// Interpolate returns the position alpha of the way from the second newest
+// recorded position to the newest. Alpha 0 is the previous sim step, 1 the
+// latest; values outside are clamped rather than extrapolated.
+func (h *TroopPositionHistory) Interpolate(alpha float64) Position {
+	n := len(h.Positions)
+	if n == 0 {
+		return Position{}
+	}
+	latest := h.Positions[(h.Index+n-1)%n]
+	previous := h.Positions[(h.Index+n-2)%n]
+	alpha = math.Min(math.Max(alpha, 0), 1)
+	return Position{
+		X: previous.X + (latest.X-previous.X)*alpha,
+		Y: previous.Y + (latest.Y-previous.Y)*alpha,
+	}
+}
+
+// RenderPosition is where to draw the troop alpha of the way through the
+// current sim step
+func (t *Troop) RenderPosition(alpha float64) Position {
+	if len(t.PositionHistory.Positions) == 0 {
+		return t.Position
+	}
+	return t.PositionHistory.Interpolate(alpha)
+}
+
+// RecordPositionHistory stores where every troop ended the tick. It runs
+// after collisions and knockback so the history holds the final positions,
+// troops standing still included.
+func RecordPositionHistory(game *Game) {
+	for i := range game.Troops {
+		troop := &game.Troops[i]
+		if troop.Active {
+			troop.PositionHistory.Record(troop.Position)
+		}
+	}
+	game.lastTickAt = time.Now()
+}
+
+// renderAlpha is how far the wall clock is into the current sim step, so
+// frames drawn between ticks can interpolate. A match that isn't ticking
+// draws the latest state. The caller must hold the game lock.
+func (g *Game) renderAlpha() float64 {
+	if g.lastTickAt.IsZero() || g.Config.TickInterval <= 0 {
+		return 1
+	}
+	return math.Min(float64(time.Since(g.lastTickAt))/float64(g.Config.TickInterval), 1)
+}
+
+// SnapshotInterpolator smooths troops between the snapshots a network
+// client receives, the same way the local client does between sim steps.
+// Push every snapshot as it arrives and draw with an alpha of the time since
+// then over the snapshot interval.
+type SnapshotInterpolator struct {
+	Latest *Snapshot // Newest pushed snapshot
+	troops map[int]*TroopPositionHistory
+}
+
+// NewSnapshotInterpolator creates an empty interpolator
+func NewSnapshotInterpolator() *SnapshotInterpolator {
+	return &SnapshotInterpolator{troops: make(map[int]*TroopPositionHistory)}
+}
+
+// Push records the troop positions of the next snapshot and forgets troops
+// that are gone
+func (s *SnapshotInterpolator) Push(snapshot *Snapshot) {
+	present := make(map[int]bool, len(snapshot.Troops))
+	for _, troop := range snapshot.Troops {
+		position := Position{X: troop.X, Y: troop.Y}
+		present[troop.ID] = true
+		if history, exists := s.troops[troop.ID]; exists {
+			history.Record(position)
+			continue
+		}
+		history := &TroopPositionHistory{}
+		history.Reset(position)
+		s.troops[troop.ID] = history
+	}
+	for id := range s.troops {
+		if !present[id] {
+			delete(s.troops, id)
+		}
+	}
+	s.Latest = snapshot
+}
+
+// TroopPosition is where to draw a troop of the latest snapshot alpha of the
+// way from the previous one
+func (s *SnapshotInterpolator) TroopPosition(id int, alpha float64) (Position, bool) {
+	history, exists := s.troops[id]
+	if !exists {
+		return Position{}, false
+	}
+	return history.Interpolate(alpha), true
+}
@@ -0,0 +1,116 @@
+// level.go
+package clashgame
+
+import "math"
+
+// Card level limits. Levels use the unified 1-15 scale, so a card's level is
+// comparable across rarities.
+const (
+	MinCardLevel       = 1
+	MaxCardLevel       = 15
+	TournamentLevelCap = 11 // Tournament standard for cards and king level
+	DefaultKingLevel   = TournamentLevelCap
+)
+
+// RarityScaling describes how a rarity's stats grow with card level
+type RarityScaling struct {
+	StartLevel int     // Level a card of this rarity is unlocked at
+	Growth     float64 // Stat multiplier gained per level above StartLevel
+}
+
+// rarityScaling maps rarity names from the CSV to their level scaling. CSV
+// stats are the values at the rarity's start level.
+var rarityScaling = map[string]RarityScaling{
+	"Common":    {StartLevel: 1, Growth: 1.10},
+	"Rare":      {StartLevel: 3, Growth: 1.10},
+	"Epic":      {StartLevel: 6, Growth: 1.10},
+	"Legendary": {StartLevel: 9, Growth: 1.10},
+	"Champion":  {StartLevel: 11, Growth: 1.10},
+}
+
+// GetRarityScaling returns the scaling for a rarity, treating unknown
+// rarities as Common
+func GetRarityScaling(rarity string) RarityScaling {
+	if scaling, exists := rarityScaling[rarity]; exists {
+		return scaling
+	}
+	return rarityScaling["Common"]
+}
+
+// ClampCardLevel keeps a level between the rarity's start level and the
+// given cap (MaxCardLevel when cap is 0)
+func ClampCardLevel(rarity string, level, cap int) int {
+	if cap <= 0 || cap > MaxCardLevel {
+		cap = MaxCardLevel
+	}
+
+	start := GetRarityScaling(rarity).StartLevel
+	if level < start {
+		level = start
+	}
+	if level > cap {
+		level = cap
+	}
+	return level
+}
+
+// LevelMultiplier returns the stat multiplier for a card of the given rarity
+// and level relative to its CSV stats
+func LevelMultiplier(rarity string, level int) float64 {
+	scaling := GetRarityScaling(rarity)
+	level = ClampCardLevel(rarity, level, MaxCardLevel)
+	return math.Pow(scaling.Growth, float64(level-scaling.StartLevel))
+}
+
+// TowerLevelMultiplier returns the stat multiplier for crown towers owned by
+// a player of the given king level. Towers scale like a Common card.
+func TowerLevelMultiplier(kingLevel int) float64 {
+	return LevelMultiplier("Common", kingLevel)
+}
+
+// ScaleStat applies a level multiplier to an integer stat
+func ScaleStat(base int, multiplier float64) int {
+	return int(math.Round(float64(base) * multiplier))
+}
+
+// DeckCard is one card in a player's deck together with its level
+type DeckCard struct {
+	Name  string // Card name
+	Level int
+}
+
+// PlayerProfile is the account data a player brings into a match
+type PlayerProfile struct {
+	KingLevel int
+	Deck      []DeckCard
+}
+
+// DefaultPlayerProfile returns a tournament-standard profile with no deck,
+// so every card plays at the tournament cap
+func DefaultPlayerProfile() PlayerProfile {
+	return PlayerProfile{KingLevel: DefaultKingLevel}
+}
+
+// CapLevels clamps the king level and every card level to cap, as
+// tournaments do
+func (p *PlayerProfile) CapLevels(cap int) {
+	if p.KingLevel > cap {
+		p.KingLevel = cap
+	}
+	for i := range p.Deck {
+		if p.Deck[i].Level > cap {
+			p.Deck[i].Level = cap
+		}
+	}
+}
+
+// CardLevel returns the level the player plays a card at. Cards that aren't
+// in the deck use the tournament standard level.
+func (p *Player) CardLevel(name string) int {
+	for _, card := range p.Deck {
+		if card.Name == name {
+			return card.Level
+		}
+	}
+	return TournamentLevelCap
+}
@@ -0,0 +1,151 @@
+// lifetime.go
+package clashgame
+
+import "math"
+
+// deathEffectDuration is how long a death effect is drawn for
+const deathEffectDuration = 15
+
+// UpdateTroopLifetimes counts down the LifeTime of landed troops and kills
+// the ones whose time ran out. Their death effects run in ProcessDeaths like
+// for any other death.
+func UpdateTroopLifetimes(game *Game) {
+	for i := range game.Troops {
+		troop := &game.Troops[i]
+		if !troop.Active || troop.IsDeploying() || troop.LifeTicks <= 0 {
+			continue
+		}
+
+		troop.LifeTicks--
+		if troop.LifeTicks == 0 {
+			troop.Health = 0
+			troop.Active = false
+			logf("Troop ID=%d expired\n", troop.ID)
+		}
+	}
+}
+
+// selfDestruct kills a kamikaze troop right after its attack went off
+func selfDestruct(troop *Troop) {
+	troop.Health = 0
+	troop.Active = false
+	troop.IsAttacking = false
+	logf("Troop ID=%d self-destructs\n", troop.ID)
+}
+
+// ProcessDeaths runs the death effects of every troop that died since the
+// last call, whether it was killed, expired or blew itself up: the
+// DeathEffect visual, DeathDamage with DeathPushBack around the body and
+// the DeathSpawnCharacter units left behind.
+func ProcessDeaths(game *Game) {
+	// Units spawned here are appended to the slice, so index instead of
+	// holding on to pointers
+	for i := 0; i < len(game.Troops); i++ {
+		troop := &game.Troops[i]
+		if troop.Active || troop.DeathHandled {
+			continue
+		}
+		troop.DeathHandled = true
+
+		template := GetTroopTemplate(troop)
+		if template == nil {
+			continue
+		}
+
+		if template.DeathEffect != "" {
+			game.Effects = append(game.Effects, Effect{
+				Name:      template.DeathEffect,
+				Position:  troop.Position,
+				Radius:    troop.Size,
+				StartTick: game.GameTime,
+				Duration:  deathEffectDuration,
+			})
+		}
+
+		if HasDeathDamage(troop) {
+			applyDeathDamage(game, *troop, template)
+		}
+
+		if template.DeathSpawnCharacter != "" && template.DeathSpawnCount > 0 {
+			spawnOnDeath(game, *troop, template)
+		}
+	}
+}
+
+// applyDeathDamage hurts enemy ground troops and buildings around a dead
+// troop and knocks the surviving troops away from it
+func applyDeathDamage(game *Game, dead Troop, template *TroopTemplate) {
+	damage := ScaleStat(GetDeathDamage(&dead), LevelMultiplier(template.Rarity, dead.Level))
+	radius := GetDeathDamageRadius(&dead) * game.Grid.CellWidth
+
+	for i := range game.Troops {
+		troop := &game.Troops[i]
+		if !troop.Active || troop.Team == dead.Team || IsFlyingTroop(troop) {
+			continue
+		}
+
+		dx := troop.Position.X - dead.Position.X
+		dy := troop.Position.Y - dead.Position.Y
+		if math.Sqrt(dx*dx+dy*dy) > radius+troop.Size/2 {
+			continue
+		}
+
+		troop.Health -= damage
+		game.recordDamage(dead.Owner, dead.Card, damage)
+		logf("Death damage from Troop ID=%d deals %d damage to Troop ID=%d (health now: %d)\n",
+			dead.ID, damage, troop.ID, troop.Health)
+		if troop.Health <= 0 {
+			troop.Active = false
+			continue
+		}
+		ApplyKnockback(troop, dx, dy, template.DeathPushBack, game.Grid)
+	}
+
+	for team := range game.Teams {
+		if team == dead.Team {
+			continue
+		}
+		buildings := append(teamBuildings(game, team), &game.Teams[team].KingBuilding.Building)
+		for _, building := range buildings {
+			if !building.Active {
+				continue
+			}
+			width, height := building.GetPixelDimensions(game.Grid)
+			if Distance(dead.Position, building.Position) > radius+math.Max(width, height)/2 {
+				continue
+			}
+
+			building.Health -= damage
+			game.recordDamage(dead.Owner, dead.Card, damage)
+			logf("Death damage from Troop ID=%d deals %d damage to Building ID=%d (health now: %d)\n",
+				dead.ID, damage, building.ID, building.Health)
+			if building.Health <= 0 {
+				building.Active = false
+			}
+		}
+	}
+}
+
+// spawnOnDeath leaves DeathSpawnCount units of DeathSpawnCharacter around
+// the dead troop. They pop out ready to act instead of deploying again.
+func spawnOnDeath(game *Game, dead Troop, template *TroopTemplate) {
+	offsets := RingFormation(template.DeathSpawnCount, 0.5)
+	for _, offset := range offsets {
+		pos := Position{
+			X: dead.Position.X + offset.X*game.Grid.CellWidth,
+			Y: dead.Position.Y + offset.Y*game.Grid.CellHeight,
+		}
+
+		troop, err := NewExtendedTroop(game.Catalog, pos.X, pos.Y, template.DeathSpawnCharacter, dead.Team, dead.Level, game.Grid)
+		if err != nil {
+			logf("Troop ID=%d can't spawn %s on death: %v\n", dead.ID, template.DeathSpawnCharacter, err)
+			return
+		}
+		troop.DeployTicks = 0
+		troop.GroupID = dead.GroupID
+		troop.TargetIndex = dead.TargetIndex
+		troop.Card = dead.Card // Credit the spawns to the card that brought them
+		troop.Owner = dead.Owner
+		SpawnTroop(troop.Troop, dead.Team, game)
+	}
+}
@@ -0,0 +1,24 @@
+// log.go
+package clashgame
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// LogOutput receives the library's progress and debug messages: loaded
+// data, hits, deploys and connections. Tools that write their own output to
+// stdout set it to io.Discard, or keep it for a -verbose flag. Set it before
+// loading data or starting a game.
+var LogOutput io.Writer = os.Stdout
+
+// logf writes a formatted message to LogOutput
+func logf(format string, args ...interface{}) {
+	fmt.Fprintf(LogOutput, format, args...)
+}
+
+// logln writes a message line to LogOutput
+func logln(args ...interface{}) {
+	fmt.Fprintln(LogOutput, args...)
+}
@@ -0,0 +1,109 @@
+// match.go
+package clashgame
+
+import "fmt"
+
+// recordDamage credits damage to the player and card that dealt it. Crown
+// towers and debug spawns have no card and aren't counted.
+func (g *Game) recordDamage(owner int, card string, damage int) {
+	if card == "" || damage <= 0 || owner < 0 || owner >= len(g.DamageByCard) {
+		return
+	}
+	if g.DamageByCard[owner] == nil {
+		g.DamageByCard[owner] = make(map[string]int)
+	}
+	g.DamageByCard[owner][card] += damage
+}
+
+// Crowns counts the enemy crown towers a team has destroyed. Taking the
+// king tower is worth all three crowns.
+func Crowns(game *Game, team int) int {
+	enemy := &game.Teams[EnemyTeam(team)]
+	if !enemy.KingBuilding.Active {
+		return 3
+	}
+	crowns := 0
+	for _, tower := range enemy.Buildings {
+		if !tower.Active {
+			crowns++
+		}
+	}
+	return crowns
+}
+
+// UpdateMatchResult applies the game mode's win conditions: a fallen king
+// tower ends the match, regulation ends on crowns or goes to overtime, and
+// in overtime or sudden death the first crown wins
+func UpdateMatchResult(game *Game) {
+	if game.Phase == PhaseEnded {
+		return
+	}
+	for team := range game.Teams {
+		if !game.Teams[team].KingBuilding.Active {
+			game.endMatch(EnemyTeam(team), fmt.Sprintf("Team %d's king tower fell", team))
+			return
+		}
+	}
+
+	mode := game.Config.Mode
+	leader := crownLeader(game)
+	if leader != NoWinner && (mode.SuddenDeath || game.Phase == PhaseOvertime) {
+		game.endMatch(leader, fmt.Sprintf("Team %d took the first crown", leader))
+		return
+	}
+
+	switch {
+	case game.Phase == PhaseRegulation && game.GameTime >= mode.RegulationTicks():
+		if leader != NoWinner {
+			game.endMatch(leader, "Time's up")
+			return
+		}
+		if mode.Overtime > 0 {
+			game.Phase = PhaseOvertime
+			logln("Overtime: the next crown wins")
+			return
+		}
+	case game.GameTime >= mode.LengthTicks():
+	default:
+		return
+	}
+
+	// Time ran out with the crowns level
+	if !mode.TowerHealthTiebreak {
+		game.endMatch(NoWinner, "Time's up")
+		return
+	}
+	health := [2]int{towerHealth(game, 0), towerHealth(game, 1)}
+	winner := NoWinner
+	if health[0] > health[1] {
+		winner = 0
+	} else if health[1] > health[0] {
+		winner = 1
+	}
+	game.endMatch(winner, "Time's up, tiebreak on tower health")
+}
+
+// crownLeader returns the team with more crowns, or NoWinner when level
+func crownLeader(game *Game) int {
+	crowns := [2]int{Crowns(game, 0), Crowns(game, 1)}
+	switch {
+	case crowns[0] > crowns[1]:
+		return 0
+	case crowns[1] > crowns[0]:
+		return 1
+	default:
+		return NoWinner
+	}
+}
+
+// endMatch decides the match; NoWinner makes it a draw
+func (g *Game) endMatch(winner int, reason string) {
+	g.Winner = winner
+	g.Phase = PhaseEnded
+	g.Running = false
+	if winner == NoWinner {
+		logf("%s, the match is a draw\n", reason)
+		return
+	}
+	logf("%s, team %d wins\n", reason, winner)
+}
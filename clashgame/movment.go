@@ -55,10 +55,19 @@ func FindPath(game *Game, start, target Position) []Position {
 	startCol, startRow := grid.PositionToCell(start)
 	targetCol, targetRow := grid.PositionToCell(target)
 	
-	// Check if start or target is invalid
-	if !grid.IsWalkableTile(startCol, startRow) {
+	// Check if start or target is invalid. A troop pushed onto a building's
+	// footprint may still walk off it.
+	if !grid.IsWalkableTerrain(startCol, startRow) {
 		return nil
 	}
+	if grid.IsWalkableTerrain(targetCol, targetRow) && grid.IsOccupied(targetCol, targetRow) {
+		// Walking to a building: aim for the closest free cell next to it
+		var found bool
+		targetCol, targetRow, found = grid.nearestFreeCell(targetCol, targetRow, startCol, startRow)
+		if !found {
+			return nil
+		}
+	}
 	if !grid.IsWalkableTile(targetCol, targetRow) {
 		return nil
 	}
@@ -155,6 +164,28 @@ func FindPath(game *Game, start, target Position) []Position {
 	return nil
 }
 
+// troopPath returns the troop's route to target. The cached route is reused
+// until the target moves to another cell or a building appears or dies, so
+// A* only runs when something relevant changed.
+func troopPath(game *Game, troop *Troop, target Position) []Position {
+	grid := game.Grid
+	goalCol, goalRow := grid.PositionToCell(target)
+	goal := [2]int{goalCol, goalRow}
+	
+	if troop.Path == nil || troop.PathGoal != goal || troop.PathVersion != grid.ObstacleVersion {
+		troop.Path = FindPath(game, troop.Position, target)
+		troop.PathGoal = goal
+		troop.PathVersion = grid.ObstacleVersion
+	}
+	
+	// Drop waypoints the troop has already reached
+	for len(troop.Path) >= 2 && Distance(troop.Position, troop.Path[1]) < grid.CellWidth/2 {
+		troop.Path = troop.Path[1:]
+	}
+	
+	return troop.Path
+}
+
 // Helper function to calculate heuristic (Manhattan distance)
 func heuristic(x1, y1, x2, y2 int) float64 {
 	dx := math.Abs(float64(x2 - x1))
@@ -291,8 +322,10 @@ func calculateCohesion(game *Game, troop *Troop) Position {
 		// Calculate distance to other troop
 		dist := Distance(troop.Position, other.Position)
 		
-		// If within cohesion radius, add to center of mass
-		if dist < cohesionRadius {
+		// If within cohesion radius, add to center of mass. Units deployed
+		// from the same card always pull together so the group stays intact.
+		sameGroup := troop.GroupID != 0 && other.GroupID == troop.GroupID
+		if dist < cohesionRadius || sameGroup {
 			centerOfMass.X += other.Position.X
 			centerOfMass.Y += other.Position.Y
 			count++
@@ -338,8 +371,8 @@ func UpdateTroopMovement(game *Game) {
 			continue
 		}
 		
-		// Skip if troop is attacking
-		if troop.IsAttacking {
+		// Skip if troop is still deploying or attacking
+		if troop.IsDeploying() || troop.IsAttacking {
 			continue
 		}
 		
@@ -347,85 +380,37 @@ func UpdateTroopMovement(game *Game) {
 		var shouldMove bool = true
 		var inAttackRange bool = false
 		
-		// First try to find nearest enemy or building within aggro range
-		if enemyTroop := FindNearestEnemyTroop(game, troop); enemyTroop != nil {
+		// Move toward the locked target picked by UpdateTroopTargets
+		if enemyTroop := findTroopByID(game, troop.TargetTroopID); enemyTroop != nil {
 			targetPos = enemyTroop.Position
-			// Check if in attack range
-			dist := Distance(troop.Position, enemyTroop.Position)
 			
-			// Calculate combined ranges (troop's collision radius + enemy's attack range)
-			combinedRange := troop.Size/2 + enemyTroop.Range * game.Grid.CellWidth
-			
-			if dist <= combinedRange {
+			if CanAttackTroop(troop, enemyTroop, game.Grid) {
 				// In attack range, stop moving but still apply push forces
 				troop.Velocity = Position{X: 0, Y: 0}
 				inAttackRange = true
 			}
-		} else if building, _ := FindNearestEnemyBuilding(game, troop); building != nil {
+		} else if building := troop.TargetBuilding; building != nil && building.Active {
 			targetPos = building.Position
-			// Check if in attack range
-			dist := Distance(troop.Position, building.Position)
-			
-			// Calculate combined ranges (troop's collision radius + building's attack range)
-			combinedRange := troop.Size/2 + building.Range * game.Grid.CellWidth
 			
-			if dist <= combinedRange {
+			if CanTroopAttackBuilding(troop, building, game.Grid) {
 				// In attack range, stop moving but still apply push forces
 				troop.Velocity = Position{X: 0, Y: 0}
 				inAttackRange = true
 			}
 		} else {
-			// No immediate targets in range, move towards enemy base
+			// No target in sight, march down the lane toward the enemy base
 			shouldMove = true
-			
-			// Determine enemy team's buildings
-			enemyTeam := 1 - troop.Team
-			
-			// First try to target nearest princess tower
-			var nearestPrincessDist float64 = math.MaxFloat64
-			var nearestPrincessPos Position
-			
-			for _, building := range game.Players[enemyTeam].Buildings {
-				if !building.Active {
-					continue
-				}
-				
-				dist := Distance(troop.Position, building.Position)
-				if dist < nearestPrincessDist {
-					nearestPrincessDist = dist
-					nearestPrincessPos = building.Position
-				}
-			}
-			
-			// If we found an active princess tower, target it
-			if nearestPrincessDist != math.MaxFloat64 {
-				// If we need to cross river to reach princess tower
-				if NeedsToCrossBridge(game, troop.Position, nearestPrincessPos) {
-					bridgePos := findNearestBridge(game, troop.Position)
-					targetPos = bridgePos
-				} else {
-					targetPos = nearestPrincessPos
-				}
-			} else {
-				// No princess towers left, go for king tower
-				kingBuilding := &game.Players[enemyTeam].KingBuilding.Building
-				
-				// If we need to cross river to reach king tower
-				if NeedsToCrossBridge(game, troop.Position, kingBuilding.Position) {
-					bridgePos := findNearestBridge(game, troop.Position)
-					targetPos = bridgePos
-				} else {
-					targetPos = kingBuilding.Position
-				}
-			}
+			targetPos = laneTarget(game, troop).Position
 		}
 		
-		// Always apply push forces, even when in attack range
-		applyPushForces(game, troop)
+		// Ground troops heading across the river go via the nearest bridge
+		if !inAttackRange && !IsFlyingTroop(troop) && NeedsToCrossBridge(game, troop.Position, targetPos) {
+			targetPos = findNearestBridge(game, troop.Position)
+		}
 		
 		if shouldMove && !inAttackRange {
 			// Find path to target
-			path := FindPath(game, troop.Position, targetPos)
+			path := troopPath(game, troop, targetPos)
 			
 			var nextPos Position
 			if path == nil || len(path) < 2 {
@@ -513,37 +498,30 @@ func UpdateTroopMovement(game *Game) {
 				troop.Position.Y += troop.Velocity.Y
 			}
 		}
-		
-		// Update position history for smooth rendering
-		historyIndex := troop.PositionHistory.Index
-		troop.PositionHistory.Positions[historyIndex] = troop.Position
-		troop.PositionHistory.Index = (historyIndex + 1) % PositionHistoryLength
 	}
 }
 
 // Helper function to find the nearest bridge position
 func findNearestBridge(game *Game, pos Position) Position {
-	// Bridge positions (from grid.go)
-	leftBridgeCol := 5
-	rightBridgeCol := 27
-	bridgeWidth := 4
-	waterStartRow := 30
-	waterEndRow := 33
-	
-	// Calculate center row of water
-	bridgeRow := (waterStartRow + waterEndRow) / 2
+	// Aim for the middle of the bridge, halfway across the river
+	bridgeRow := (game.Grid.RiverStartRow + game.Grid.RiverEndRow) / 2
 	
 	// Convert current position to grid coordinates
 	currentCol, _ := game.Grid.PositionToCell(pos)
 	
-	// Determine which bridge is closer
-	var targetBridgeCol int
-	if currentCol < GridColumns/2 {
-		// Use left bridge
-		targetBridgeCol = leftBridgeCol + bridgeWidth/2
-	} else {
-		// Use right bridge
-		targetBridgeCol = rightBridgeCol + bridgeWidth/2
+	// Pick the bridge whose center column is closest
+	targetBridgeCol := currentCol
+	bestDistance := math.MaxInt
+	for _, bridge := range game.Grid.Bridges {
+		center := bridge.Col + bridge.Width/2
+		distance := center - currentCol
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance < bestDistance {
+			bestDistance = distance
+			targetBridgeCol = center
+		}
 	}
 	
 	// Convert bridge position to world coordinates
@@ -558,9 +536,9 @@ func NeedsToCrossBridge(game *Game, troopPos, targetPos Position) bool {
 		return false
 	}
 	
-	// Water row range (from grid.go)
-	waterStartRow := 30
-	waterEndRow := 33
+	// Water row range of the arena
+	waterStartRow := game.Grid.RiverStartRow
+	waterEndRow := game.Grid.RiverEndRow
 	
 	// Get troop and target rows
 	_, troopRow := game.Grid.PositionToCell(troopPos)
@@ -597,94 +575,14 @@ func InitTroopMovement(troop *Troop) {
 	// Faster troops should have higher acceleration
 	troop.MaxAcceleration = 0.2
 	
-	if troop.Speed > 0.15 {
+	if troop.Speed > SpeedToCellsPerTick(60) {
 		// Fast troops have more responsive movement
 		troop.MaxAcceleration = 0.25
-	} else if troop.Speed < 0.1 {
+	} else if troop.Speed < SpeedToCellsPerTick(40) {
 		// Slow, heavy troops have less responsive movement
 		troop.MaxAcceleration = 0.1
 	}
 	
 	// Initialize position history for smooth rendering
-	troop.PositionHistory = TroopPositionHistory{
-		Positions: make([]Position, PositionHistoryLength),
-		Index:     0,
-	}
-	
-	// Fill history with current position
-	for i := 0; i < PositionHistoryLength; i++ {
-		troop.PositionHistory.Positions[i] = troop.Position
-	}
+	troop.PositionHistory.Reset(troop.Position)
 }
-
-// Add these constants at the top of the file
-const (
-	// Existing constants
-	SameTeamPushFactor    = 0.5
-	DifferentTeamPushFactor = 0.5  
-	
-	// New constants for rigid body physics
-	PushForceStrength = 0.8
-	PushRadius = 0.8  // Slightly larger than separation radius
-	PushDamping = 0.8 // How quickly push forces dissipate
-)
-
-// Add this new function for rigid body physics
-func applyPushForces(game *Game, troop *Troop) {
-	pushRadius := PushRadius * game.Grid.CellWidth
-	
-	for i := range game.Troops {
-		other := &game.Troops[i]
-		
-		// Skip if not active or same troop
-		if !other.Active || other.ID == troop.ID {
-			continue
-		}
-		
-		// Calculate distance and direction
-		dx := other.Position.X - troop.Position.X
-		dy := other.Position.Y - troop.Position.Y
-		dist := math.Sqrt(dx*dx + dy*dy)
-		
-		// If within push radius, apply force
-		if dist < pushRadius {
-			// Calculate overlap
-			overlap := pushRadius - dist
-			
-			// Determine push factor based on team
-			pushFactor := DifferentTeamPushFactor
-			if other.Team == troop.Team {
-				pushFactor = SameTeamPushFactor
-			}
-			
-			// Calculate push force (stronger when closer)
-			force := (overlap / pushRadius) * PushForceStrength * pushFactor
-			
-			// Normalize direction
-			if dist > 0 {
-				dx = dx / dist
-				dy = dy / dist
-			}
-			
-			// Apply push force
-			pushX := dx * force * game.Grid.CellWidth
-			pushY := dy * force * game.Grid.CellHeight
-			
-			// Apply to both troops (equal and opposite forces)
-			troop.Position.X -= pushX * PushDamping
-			troop.Position.Y -= pushY * PushDamping
-			other.Position.X += pushX * PushDamping
-			other.Position.Y += pushY * PushDamping
-			
-			// Also affect velocities slightly
-			if dist > 0 {
-				// Transfer some momentum
-				momentumTransfer := 0.3
-				troop.Velocity.X -= dx * momentumTransfer * force
-				troop.Velocity.Y -= dy * momentumTransfer * force
-				other.Velocity.X += dx * momentumTransfer * force
-				other.Velocity.Y += dy * momentumTransfer * force
-			}
-		}
-	}
-}
\ No newline at end of file
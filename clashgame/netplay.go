@@ -0,0 +1,349 @@
+// netplay.go
+package clashgame
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Network play runs over TCP. A client opens a connection and sends one
+// JSON ClientMessage per line: first a join naming the seat it plays, then
+// commands and acknowledgements of the snapshots it received. The server
+// answers the join with one JSON JoinReply line and from then on sends a
+// BinaryCodec delta per tick, each prefixed with its length as a 4-byte
+// big-endian integer.
+
+// Client message types
+const (
+	MessageJoin    = "join"
+	MessageCommand = "command"
+	MessageAck     = "ack"
+)
+
+// maxSnapshotFrame bounds the size of a snapshot message a client accepts
+const maxSnapshotFrame = 1 << 20
+
+// snapshotWriteTimeout is how long the server waits on a slow client before
+// dropping it
+const snapshotWriteTimeout = time.Second
+
+// ClientMessage is a line a network client sends
+type ClientMessage struct {
+	Type    string   `json:"type"`
+	Player  int      `json:"player,omitempty"`  // Seat to play, for a join
+	Command *Command `json:"command,omitempty"` // For a command; Player is filled in by the server
+	Tick    int      `json:"tick,omitempty"`    // Snapshot received, for an ack
+}
+
+// JoinReply answers a join. Error is empty when the seat was taken.
+type JoinReply struct {
+	Player int    `json:"player"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Server lets network clients play the open seats of a game. Commands go
+// through the game's queue like local input, and Broadcast streams every
+// client the match state from its own SnapshotStream.
+type Server struct {
+	game     *Game
+	codec    SnapshotCodec
+	mu       sync.Mutex
+	open     map[int]bool          // Seats network clients may take
+	clients  map[int]*remoteClient // By seat
+	listener net.Listener
+	lastTick int // Tick of the last broadcast snapshot
+}
+
+// remoteClient is a connected player
+type remoteClient struct {
+	player int
+	conn   net.Conn
+	mu     sync.Mutex // Guards stream between Broadcast and incoming acks
+	stream *SnapshotStream
+}
+
+// NewServer creates a server offering the given seats of a game to network
+// players. Seats played locally or by bots must be left out.
+func NewServer(game *Game, seats []int) *Server {
+	s := &Server{
+		game:     game,
+		codec:    BinaryCodec{},
+		open:     make(map[int]bool),
+		clients:  make(map[int]*remoteClient),
+		lastTick: -1,
+	}
+	for _, seat := range seats {
+		s.open[seat] = true
+	}
+	return s
+}
+
+// ListenAndServe accepts clients on a TCP address such as ":9000"
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts clients on a listener until Close is called
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops accepting clients and disconnects the connected ones
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, client := range s.clients {
+		client.conn.Close()
+	}
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// Broadcast sends every client the state of the latest tick. StartGameLoop
+// calls it about 30 times a second; ticks already sent are skipped.
+func (s *Server) Broadcast() {
+	snapshot := s.game.Snapshot()
+
+	s.mu.Lock()
+	if snapshot.Tick == s.lastTick {
+		s.mu.Unlock()
+		return
+	}
+	s.lastTick = snapshot.Tick
+	clients := make([]*remoteClient, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.Unlock()
+
+	for _, client := range clients {
+		if err := client.send(s.codec, snapshot.ForPlayer(client.player)); err != nil {
+			logf("Dropping player %d: %v\n", client.player, err)
+			client.conn.Close()
+		}
+	}
+}
+
+// handle runs one client connection: the join, then its messages until it
+// disconnects
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	decoder := json.NewDecoder(conn)
+
+	var join ClientMessage
+	if err := decoder.Decode(&join); err != nil || join.Type != MessageJoin {
+		logf("Client %s didn't join\n", conn.RemoteAddr())
+		return
+	}
+	client, err := s.join(conn, join.Player)
+	if err != nil {
+		logf("Client %s can't join: %v\n", conn.RemoteAddr(), err)
+		return
+	}
+	defer s.leave(client)
+	logf("Client %s joined as player %d\n", conn.RemoteAddr(), client.player)
+
+	for {
+		var msg ClientMessage
+		if err := decoder.Decode(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case MessageCommand:
+			if msg.Command == nil {
+				continue
+			}
+			// A client only ever plays its own seat
+			cmd := *msg.Command
+			cmd.Player = client.player
+			s.game.SubmitCommand(cmd)
+		case MessageAck:
+			client.mu.Lock()
+			client.stream.Ack(msg.Tick)
+			client.mu.Unlock()
+		default:
+			logf("Player %d sent unknown message %q\n", client.player, msg.Type)
+		}
+	}
+}
+
+// join seats a client and sends it the reply. The client is only added
+// once the reply is written, so no snapshot can arrive before it.
+func (s *Server) join(conn net.Conn, player int) (*remoteClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	switch {
+	case !s.open[player]:
+		err = fmt.Errorf("seat %d isn't open to network players", player)
+	case s.clients[player] != nil:
+		err = fmt.Errorf("seat %d is taken", player)
+	}
+	reply := JoinReply{Player: player}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	conn.SetWriteDeadline(time.Now().Add(snapshotWriteTimeout))
+	if writeErr := json.NewEncoder(conn).Encode(reply); err == nil && writeErr != nil {
+		err = writeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client := &remoteClient{player: player, conn: conn, stream: NewSnapshotStream(TicksPerSecond)}
+	s.clients[player] = client
+	return client, nil
+}
+
+// leave frees a client's seat
+func (s *Server) leave(client *remoteClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients[client.player] == client {
+		delete(s.clients, client.player)
+	}
+	logf("Player %d left\n", client.player)
+}
+
+// send writes the delta to a snapshot as one frame
+func (c *remoteClient) send(codec SnapshotCodec, snapshot *Snapshot) error {
+	c.mu.Lock()
+	data, err := codec.EncodeDelta(c.stream.Next(snapshot))
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	frame := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(data)), uint32(len(data)))
+	c.conn.SetWriteDeadline(time.Now().Add(snapshotWriteTimeout))
+	_, err = c.conn.Write(append(frame, data...))
+	return err
+}
+
+// Client is the network side of a seat: it sends commands and rebuilds the
+// snapshots the server streams
+type Client struct {
+	Player   int
+	conn     net.Conn
+	reader   *bufio.Reader
+	codec    SnapshotCodec
+	mu       sync.Mutex // Serializes messages to the server
+	encoder  *json.Encoder
+	received map[int]*Snapshot // Rebuilt snapshots a delta may be based on, by tick
+}
+
+// Dial connects to a server and joins as the player in a seat
+func Dial(addr string, player int) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		Player:   player,
+		conn:     conn,
+		reader:   bufio.NewReader(conn),
+		codec:    BinaryCodec{},
+		encoder:  json.NewEncoder(conn),
+		received: make(map[int]*Snapshot),
+	}
+
+	if err := c.write(ClientMessage{Type: MessageJoin, Player: player}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("joining as player %d: %v", player, err)
+	}
+	var reply JoinReply
+	if err := json.Unmarshal(line, &reply); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("joining as player %d: %v", player, err)
+	}
+	if reply.Error != "" {
+		conn.Close()
+		return nil, fmt.Errorf("joining as player %d: %s", player, reply.Error)
+	}
+	return c, nil
+}
+
+// Send submits a command for the client's seat
+func (c *Client) Send(cmd Command) error {
+	cmd.Player = c.Player
+	return c.write(ClientMessage{Type: MessageCommand, Command: &cmd})
+}
+
+// Receive waits for the next snapshot, rebuilds it and acknowledges it
+func (c *Client) Receive() (*Snapshot, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(c.reader, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxSnapshotFrame {
+		return nil, fmt.Errorf("snapshot message of %d bytes is too large", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return nil, err
+	}
+
+	delta, err := c.codec.DecodeDelta(data)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := delta.Apply(c.received[delta.BaseTick])
+	if err != nil {
+		return nil, err
+	}
+
+	// The server never goes back to a base older than the one it used last
+	for tick := range c.received {
+		if tick < delta.BaseTick {
+			delete(c.received, tick)
+		}
+	}
+	c.received[snapshot.Tick] = snapshot
+	return snapshot, c.write(ClientMessage{Type: MessageAck, Tick: snapshot.Tick})
+}
+
+// Close disconnects from the server, freeing the seat
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// write sends one message line
+func (c *Client) write(msg ClientMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.encoder.Encode(msg)
+}
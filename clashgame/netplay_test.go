@@ -0,0 +1,103 @@
+// netplay_test.go
+package clashgame
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// startServer serves a game's seats on a loopback port and returns its
+// address
+func startServer(t *testing.T, game *Game, seats ...int) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("can't listen on loopback: %v", err)
+	}
+	game.Server = NewServer(game, seats)
+	go game.Server.Serve(listener)
+	t.Cleanup(func() { game.Server.Close() })
+	return listener.Addr().String()
+}
+
+// TestNetworkPlayer joins a 2v2 game over TCP, plays a card and checks the
+// streamed snapshots follow the match, with only the client's own hand
+func TestNetworkPlayer(t *testing.T) {
+	config := DefaultGameConfig()
+	config.TeamSize = 2
+	game := NewGame(testCatalog(t), nil, config)
+	for player := 0; player < 3; player++ {
+		game.AddBot(NewBot(player, BotEasy, int64(player+1)))
+	}
+	remote := &game.Players[3]
+	for _, name := range DefaultBotDeck {
+		remote.Deck = append(remote.Deck, DeckCard{Name: name, Level: TournamentLevelCap})
+	}
+	remote.dealHand()
+	game.Running = true
+	addr := startServer(t, game, 3)
+
+	if _, err := Dial(addr, 1); err == nil {
+		t.Error("joined a seat a bot plays")
+	}
+	client, err := Dial(addr, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := Dial(addr, 3); err == nil {
+		t.Error("joined a seat another client plays")
+	}
+
+	game.Players[3].Elixir = float64(game.Players[3].ElixirMax)
+	cmd := deployCell(t, game, 3)
+	cmd.Player = 0 // The server must play it for the client's seat
+	if err := client.Send(cmd); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); game.Commands.Len() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("the server never queued the client's command")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	team := game.Players[3].Team
+	deployed := false
+	for i := 0; i < 100; i++ {
+		game.Tick()
+		game.Server.Broadcast()
+		snapshot, err := client.Receive()
+		if err != nil {
+			t.Fatalf("tick %d: %v", game.GameTime, err)
+		}
+		if snapshot.Tick != game.GameTime {
+			t.Fatalf("got the snapshot of tick %d on tick %d", snapshot.Tick, game.GameTime)
+		}
+		for player, state := range snapshot.Players {
+			if (player == 3) != (len(state.Hand) > 0) {
+				t.Fatalf("player %d's hand is %v in player 3's snapshot", player, state.Hand)
+			}
+		}
+		for _, troop := range snapshot.Troops {
+			deployed = deployed || troop.Team == team
+		}
+	}
+
+	played := false
+	for _, logged := range game.CommandLog {
+		if logged.Player == 3 && logged.Card == cmd.Card {
+			played = true
+		}
+		if logged.Player == 0 && logged.Card == cmd.Card && logged.Col == cmd.Col && logged.Row == cmd.Row {
+			t.Error("the client's command was played for player 0")
+		}
+	}
+	if !played {
+		t.Error("the client's card was never played")
+	}
+	if !deployed {
+		t.Error("no troop of the client's team showed up in its snapshots")
+	}
+}
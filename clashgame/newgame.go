@@ -1,59 +1,89 @@
 package clashgame
 
-import (
-	"fmt"
-	"image/color"
-)
+import "image/color"
 
-func NewGame() *Game {
-    // Create a new grid system first
-    grid := NewGridSystem()
+// teamColors are the colors of team 0 and team 1 and their players
+var teamColors = [NumTeams]color.RGBA{
+    {255, 0, 0, 255},
+    {0, 0, 255, 255},
+}
+
+// NewGame creates a match on an arena (nil means DefaultArena) that uses the
+// given template catalog and settings. The config's TeamSize decides how
+// many players each team has; see PlayerTeam for who plays where. Profiles
+// supply each player's king level and deck; players without one get
+// DefaultPlayerProfile. Levels above the config's LevelCap are capped, and
+// modes with draft or random decks replace the profile decks.
+func NewGame(catalog *Catalog, arena *Arena, config GameConfig, profiles ...PlayerProfile) *Game {
+    if arena == nil {
+        arena = DefaultArena()
+    }
+    
+    playerProfiles := make([]PlayerProfile, NumTeams*max(config.TeamSize, 1))
+    for i := range playerProfiles {
+        playerProfiles[i] = DefaultPlayerProfile()
+        if i < len(profiles) {
+            playerProfiles[i] = profiles[i]
+            // Capping must not change the caller's deck
+            playerProfiles[i].Deck = append([]DeckCard(nil), profiles[i].Deck...)
+        }
+        if config.LevelCap > 0 {
+            playerProfiles[i].CapLevels(config.LevelCap)
+        }
+    }
+    
+    // Draft and random modes bring their own decks
+    config.Mode.dealDecks(catalog, config.Seed, playerProfiles)
+    
+    // Create the arena's grid system first
+    grid := NewGridSystem(arena)
     
     // Load the tilemap
-    err := grid.LoadTileMap("clashgame/csv/tilemap.csv")
+    err := grid.LoadTileMap(arena.data(), arena.Tilemap)
     if err != nil {
-        fmt.Println("Error loading tilemap:", err)
+        logln("Error loading tilemap:", err)
     }
     
     game := &Game{
-        Players: [2]Player{
-            NewPlayer(color.RGBA{255, 0, 0, 255}, true, 0, grid),
-            NewPlayer(color.RGBA{0, 0, 255, 255}, false, 1, grid),
-        },
+        Config: config,
         Grid: grid,
+        Arena: arena,
         BuildingMap: make(map[int]*Building),
         NextBuildingID: 1,
         ShowCSVPath: true, // Set to true to show CSV path
-        CSVPath: "clashgame/csv/tilemap.csv", // Set the default CSV path
+        CSVPath: arena.Tilemap, // Show which tilemap the arena uses
+        Catalog: catalog,
+        Commands: NewCommandQueue(),
+        Winner: NoWinner,
+        DamageByCard: make([]map[string]int, len(playerProfiles)),
     }
-
-    SetGameInstance(game)
     
-    // Assign IDs to all buildings and add them to the map
-    // Player 0 buildings
-    game.Players[0].KingBuilding.Building.ID = game.NextBuildingID
-    game.BuildingMap[game.NextBuildingID] = &game.Players[0].KingBuilding.Building
-    game.NextBuildingID++
-    
-    for i := range game.Players[0].Buildings {
-        game.Players[0].Buildings[i].ID = game.NextBuildingID
-        game.BuildingMap[game.NextBuildingID] = &game.Players[0].Buildings[i]
-        game.NextBuildingID++
+    // A team's towers follow the king level of its first player
+    for team := range game.Teams {
+        game.Teams[team] = NewTeam(teamColors[team], arena.Towers[team], playerProfiles[team].KingLevel, grid)
     }
-    
-    // Player 1 buildings
-    game.Players[1].KingBuilding.Building.ID = game.NextBuildingID
-    game.BuildingMap[game.NextBuildingID] = &game.Players[1].KingBuilding.Building
-    game.NextBuildingID++
-    
-    for i := range game.Players[1].Buildings {
-        game.Players[1].Buildings[i].ID = game.NextBuildingID
-        game.BuildingMap[game.NextBuildingID] = &game.Players[1].Buildings[i]
-        game.NextBuildingID++
+    for i, profile := range playerProfiles {
+        team := PlayerTeam(i)
+        game.Players = append(game.Players, NewPlayer(teamColors[team], team, profile, config))
     }
     
-    // Initialize the projectile system
-    InitializeProjectileSystem()
+    // Assign IDs and teams to all towers, add them to the map and block
+    // their cells on the grid
+    for team := range game.Teams {
+        game.registerTower(&game.Teams[team].KingBuilding.Building, team)
+        for i := range game.Teams[team].Buildings {
+            game.registerTower(&game.Teams[team].Buildings[i], team)
+        }
+    }
     
     return game
-}
\ No newline at end of file
+}
+
+// registerTower gives a crown tower its ID and team and makes it an obstacle
+func (game *Game) registerTower(building *Building, team int) {
+    building.ID = game.NextBuildingID
+    building.Team = team
+    game.BuildingMap[building.ID] = building
+    game.NextBuildingID++
+    game.Grid.AddObstacle(building)
+}
@@ -2,13 +2,11 @@
 package clashgame
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
 	"image/color"
-	"io"
+	"io/fs"
 	"math"
-	"os"
-	"strings"
 )
 
 func clearAttackingStateOfSource(game *Game, sourceID int) {
@@ -20,7 +18,7 @@ func clearAttackingStateOfSource(game *Game, sourceID int) {
     for i := range game.Troops {
         if game.Troops[i].ID == sourceID && game.Troops[i].Active {
             game.Troops[i].IsAttacking = false
-            fmt.Printf("Cleared attacking state for Troop ID=%d after its projectile defeated a target\n", sourceID)
+            logf("Cleared attacking state for Troop ID=%d after its projectile defeated a target\n", sourceID)
             break
         }
     }
@@ -28,25 +26,25 @@ func clearAttackingStateOfSource(game *Game, sourceID int) {
 
 // ProjectileTemplate holds properties from the projectile CSV
 type ProjectileTemplate struct {
-	Name                 string
-	Rarity               string
-	Speed                float64
-	Scale                float64
-	Homing               bool
-	HomingTime           float64
-	HomingMinDistance    float64
-	Damage               int
-	CrownTowerDamagePercent float64
-	Pushback             float64
-	PushbackAll          bool
-	Radius               float64
-	AoeToAir             bool
-	AoeToGround          bool
-	OnlyEnemies          bool
-	MaximumTargets       int
-	ProjectileRadius     float64
-	TrailEffect          string
-	ConstantHeight       bool
+	Name                 string  `csv:"Name"`
+	Rarity               string  `csv:"Rarity"`
+	Speed                float64 `csv:"Speed" unit:"speed"`
+	Scale                float64 `csv:"Scale" unit:"percent"`
+	Homing               bool    `csv:"Homing"`
+	HomingTime           float64 `csv:"HomingTime" unit:"ms"`
+	HomingMinDistance    float64 `csv:"HomingMinDistance" unit:"distance"`
+	Damage               int     `csv:"Damage"`
+	CrownTowerDamagePercent float64 `csv:"CrownTowerDamagePercent"`
+	Pushback             float64 `csv:"Pushback" unit:"distance"`
+	PushbackAll          bool    `csv:"PushbackAll"`
+	Radius               float64 `csv:"Radius" unit:"distance"`
+	AoeToAir             bool    `csv:"AoeToAir"`
+	AoeToGround          bool    `csv:"AoeToGround"`
+	OnlyEnemies          bool    `csv:"OnlyEnemies"`
+	MaximumTargets       int     `csv:"MaximumTargets"`
+	ProjectileRadius     float64 `csv:"ProjectileRadius" unit:"distance"`
+	TrailEffect          string  `csv:"TrailEffect"`
+	ConstantHeight       bool    `csv:"ConstantHeight"`
 }
 
 // Projectile represents a projectile in the game
@@ -55,7 +53,7 @@ type Projectile struct {
 	Position       Position    // Current position
 	StartPosition  Position    // Starting position
 	TargetPosition Position    // Target position (for non-homing projectiles)
-	TargetEntity   interface{} // Target troop or building (for homing projectiles)
+	TargetEntity   interface{} // Target troop or building, followed by homing projectiles
 	Direction      Position    // Normalized direction vector
 	Speed          float64     // Movement speed (grid cells per tick)
 	Damage         int         // Damage to deal on hit
@@ -73,17 +71,17 @@ type Projectile struct {
 	MaxLifeTime    int         // Maximum lifetime of projectile (prevents infinite projectiles)
 	SourceID       int         // ID of the entity that fired this projectile (to prevent self-hits)
 	Template       *ProjectileTemplate // Reference to the template
+	Card           string      // Card credited with the damage ("" for crown towers)
+	Owner          int         // Player credited with the damage, see Troop.Owner
 }
 
-// ProjectileTemplateMap is a map of projectile names to their templates
-var ProjectileTemplateMap map[string]*ProjectileTemplate
-// Modify the InitializeProjectileSystem function in projectile.go to ensure "normal" is properly added
-func InitializeProjectileSystem() {
-    // Initialize the map
-    ProjectileTemplateMap = make(map[string]*ProjectileTemplate)
+// defaultProjectileTemplates returns the built-in projectiles that every
+// catalog starts from, including the "normal" shot used by buildings
+func defaultProjectileTemplates() map[string]*ProjectileTemplate {
+    projectiles := make(map[string]*ProjectileTemplate)
     
     // Add "normal" projectile for buildings
-    ProjectileTemplateMap["normal"] = &ProjectileTemplate{
+    projectiles["normal"] = &ProjectileTemplate{
         Name:              "normal",
         Rarity:            "Common",
         Speed:             0.7,
@@ -98,7 +96,7 @@ func InitializeProjectileSystem() {
     }
     
     // Add "ArcherArrow" specific projectile
-    ProjectileTemplateMap["ArcherArrow"] = &ProjectileTemplate{
+    projectiles["ArcherArrow"] = &ProjectileTemplate{
         Name:              "ArcherArrow",
         Rarity:            "Common",
         Speed:             1.0,  // Faster than regular arrow
@@ -111,26 +109,23 @@ func InitializeProjectileSystem() {
         OnlyEnemies:       true,
         ProjectileRadius:  0.15,
     }
-
+    
+    return projectiles
 }
 
-// Updated CreateProjectile function to consider template damage
-func CreateProjectile(templateName string, source Position, target Position, damage int, team int, sourceID int) *Projectile {
+// CreateProjectile fires the named projectile from the catalog. Damage is the
+// shooter's own damage and multiplier its level scaling, see ShotDamage.
+func CreateProjectile(catalog *Catalog, templateName string, source Position, target Position, damage int, multiplier float64, team int, sourceID int) *Projectile {
     // Skip if template name is empty or "none"
     if templateName == "" || templateName == "none" {
         return nil
     }
     
-    // Ensure ProjectileTemplateMap is initialized
-    if ProjectileTemplateMap == nil {
-        InitializeProjectileSystem()
-    }
-    
     // Get the template
-    template, exists := ProjectileTemplateMap[templateName]
+    template, exists := catalog.Projectile(templateName)
     if !exists {
         // Log warning
-        fmt.Printf("Warning: Projectile template '%s' not found, using default\n", templateName)
+        logf("Warning: Projectile template '%s' not found, using default\n", templateName)
         
         // Create a default template for missing projectiles
         template = &ProjectileTemplate{
@@ -146,11 +141,29 @@ func CreateProjectile(templateName string, source Position, target Position, dam
             OnlyEnemies:       true,
             ProjectileRadius:  0.2,
         }
-        
-        // Add it to the map for future use
-        ProjectileTemplateMap[templateName] = template
     }
     
+    return NewProjectile(template, source, target, ShotDamage(template, damage, multiplier), team, sourceID)
+}
+
+// ShotDamage returns the damage of one shot of a projectile. Damage is the
+// shooter's own damage, already scaled to its level. A projectile with damage
+// of its own replaces it, scaled by the shooter's level multiplier, so
+// troops, buildings, crown towers and spells all follow their level.
+func ShotDamage(template *ProjectileTemplate, damage int, multiplier float64) int {
+    if template.Damage > 0 {
+        return ScaleStat(template.Damage, multiplier)
+    }
+    return damage
+}
+
+// NewProjectile creates a projectile from a template that deals damage on
+// impact, see ShotDamage. Troops fire the copy their own template holds, so
+// reloading the catalog doesn't change the shots of troops already on the
+// field.
+func NewProjectile(template *ProjectileTemplate, source Position, target Position, damage int, team int, sourceID int) *Projectile {
+    templateName := template.Name
+    
     // Calculate direction vector
     dx := target.X - source.X
     dy := target.Y - source.Y
@@ -211,15 +224,6 @@ func CreateProjectile(templateName string, source Position, target Position, dam
         }
     }
     
-    // IMPORTANT FIX: Use damage from the template if available
-    // Otherwise fall back to the provided damage parameter
-    projectileDamage := damage
-    if template.Damage > 0 {
-        projectileDamage = template.Damage
-        fmt.Printf("Using template damage %d for projectile %s (instead of troop damage %d)\n", 
-                 template.Damage, templateName, damage)
-    }
-    
     // Create the projectile
     projectile := &Projectile{
         Name:           templateName,
@@ -228,33 +232,218 @@ func CreateProjectile(templateName string, source Position, target Position, dam
         TargetPosition: target,
         Direction:      direction,
         Speed:          speed,
-        Damage:         projectileDamage,  // Now using template damage
+        Damage:         damage,
         Radius:         template.Radius,
         Color:          projectileColor,
         Size:           size,
         Active:         true,
         Team:           team,
         IsHoming:       template.Homing,
-        MaxHomingTime:  template.HomingTime,
+        MaxHomingTime:  float64(SecondsToTicks(template.HomingTime)),
         AoeToAir:       template.AoeToAir,
         AoeToGround:    template.AoeToGround,
         LifeTime:       0,
-        MaxLifeTime:    300, // 5 seconds at 60 ticks per second
+        MaxLifeTime:    SecondsToTicks(5),
         SourceID:       sourceID,
         Template:       template,
     }
     
     // Log projectile creation
-    fmt.Printf("Created projectile: Name=%s, Damage=%d, Speed=%.2f, Size=%.2f\n", 
-             templateName, projectileDamage, speed, size)
+    logf("Created projectile: Name=%s, Damage=%d, Speed=%.2f, Size=%.2f\n", 
+             templateName, damage, speed, size)
     
     return projectile
 }
 
-func (p *Projectile) Update(game *Game) {}
+// Update moves the projectile one tick towards its target and resolves the
+// impact when it arrives
+func (p *Projectile) Update(game *Game) {
+    p.LifeTime++
+    if p.LifeTime > p.MaxLifeTime {
+        p.Active = false
+        return
+    }
+    
+    // Homing projectiles follow their target for as long as it is alive
+    if p.IsHoming {
+        if pos, alive := projectileTargetPosition(game, p.TargetEntity); alive {
+            p.TargetPosition = pos
+        }
+    }
+    
+    dx := p.TargetPosition.X - p.Position.X
+    dy := p.TargetPosition.Y - p.Position.Y
+    dist := math.Sqrt(dx*dx + dy*dy)
+    step := p.Speed * game.Grid.CellWidth
+    
+    // Arrived this tick
+    if dist <= step {
+        p.Position = p.TargetPosition
+        p.HandleImpact(game, p.Position)
+        p.Active = false
+        return
+    }
+    
+    p.Direction = Position{X: dx / dist, Y: dy / dist}
+    p.Position.X += p.Direction.X * step
+    p.Position.Y += p.Direction.Y * step
+}
 
 // HandleImpact deals damage when a projectile hits something
-func (p *Projectile) HandleImpact(game *Game, impactPos Position) {}
+func (p *Projectile) HandleImpact(game *Game, impactPos Position) {
+    // Single-target projectiles hit whatever they were fired at. If it is
+    // gone they hit at most the one enemy troop the shot itself touches.
+    if p.Radius <= 0 {
+        switch target := p.TargetEntity.(type) {
+        case *Troop:
+            if troop := findTroopByID(game, target.ID); troop != nil {
+                p.damageTroop(game, troop, impactPos)
+                return
+            }
+        case *Building:
+            if target.Active {
+                p.damageBuilding(game, target)
+                return
+            }
+        }
+        if troop := p.strayHit(game, impactPos); troop != nil {
+            p.damageTroop(game, troop, impactPos)
+        }
+        return
+    }
+    
+    // Area projectiles hit everything in the blast radius, at least one
+    // cell wide
+    radius := math.Max(p.Radius, 1.0) * game.Grid.CellWidth
+    
+    for i := range game.Troops {
+        troop := &game.Troops[i]
+        if !troop.Active || troop.Team == p.Team {
+            continue
+        }
+        flying := IsFlyingTroop(troop)
+        if (flying && !p.AoeToAir) || (!flying && !p.AoeToGround) {
+            continue
+        }
+        if Distance(impactPos, troop.Position) <= radius+troop.Size/2 {
+            p.damageTroop(game, troop, impactPos)
+        }
+    }
+    
+    for team := range game.Teams {
+        if team == p.Team {
+            continue
+        }
+        buildings := append(teamBuildings(game, team), &game.Teams[team].KingBuilding.Building)
+        for _, building := range buildings {
+            if !building.Active {
+                continue
+            }
+            width, height := building.GetPixelDimensions(game.Grid)
+            if Distance(impactPos, building.Position) <= radius+math.Max(width, height)/2 {
+                p.damageBuilding(game, building)
+            }
+        }
+    }
+}
+
+// strayHit returns the enemy troop closest to where a single-target shot
+// landed without its target, if the shot's own ProjectileRadius touches it
+func (p *Projectile) strayHit(game *Game, impactPos Position) *Troop {
+    reach := 0.0
+    if p.Template != nil {
+        reach = p.Template.ProjectileRadius * game.Grid.CellWidth
+    }
+    
+    var closest *Troop
+    closestDistance := math.MaxFloat64
+    for i := range game.Troops {
+        troop := &game.Troops[i]
+        if !troop.Active || troop.Team == p.Team {
+            continue
+        }
+        dist := Distance(impactPos, troop.Position)
+        if dist <= reach+troop.Size/2 && dist < closestDistance {
+            closest = troop
+            closestDistance = dist
+        }
+    }
+    return closest
+}
+
+// damageTroop applies the projectile's damage to a troop and knocks
+// survivors away from the impact if the template has Pushback
+func (p *Projectile) damageTroop(game *Game, troop *Troop, impactPos Position) {
+    troop.Health -= p.Damage
+    game.recordDamage(p.Owner, p.Card, p.Damage)
+    logf("Projectile %s deals %d damage to Troop ID=%d (health now: %d)\n",
+               p.Name, p.Damage, troop.ID, troop.Health)
+    
+    if troop.Health <= 0 {
+        troop.Active = false
+        logf("Troop ID=%d defeated by projectile %s\n", troop.ID, p.Name)
+        clearAttackingStateOfSource(game, p.SourceID)
+        return
+    }
+    
+    if p.Template != nil && p.Template.Pushback > 0 {
+        // Push away from the impact, or along the flight path for a
+        // direct hit on the troop's center
+        dirX := troop.Position.X - impactPos.X
+        dirY := troop.Position.Y - impactPos.Y
+        if dirX == 0 && dirY == 0 {
+            dirX, dirY = p.Direction.X, p.Direction.Y
+        }
+        ApplyKnockback(troop, dirX, dirY, p.Template.Pushback, game.Grid)
+    }
+}
+
+// damageBuilding applies the projectile's damage to a building. Crown towers
+// take it reduced by the template's crown tower modifier (e.g. -70 means
+// towers take 30%); buildings placed from cards take it in full.
+func (p *Projectile) damageBuilding(game *Game, building *Building) {
+    damage := p.Damage
+    if building.IsCrownTower() && p.Template != nil && p.Template.CrownTowerDamagePercent != 0 {
+        damage = int(float64(damage) * (100 + p.Template.CrownTowerDamagePercent) / 100)
+    }
+    
+    building.Health -= damage
+    game.recordDamage(p.Owner, p.Card, damage)
+    logf("Projectile %s deals %d damage to Building ID=%d (health now: %d)\n",
+               p.Name, damage, building.ID, building.Health)
+    
+    if building.Health <= 0 {
+        building.Active = false
+        logf("Building ID=%d destroyed by projectile %s\n", building.ID, p.Name)
+    }
+}
+
+// projectileTargetPosition returns the current position of a homing target
+func projectileTargetPosition(game *Game, target interface{}) (Position, bool) {
+    switch t := target.(type) {
+    case *Troop:
+        // The troop slice may have been reallocated since firing, so look
+        // the target up by ID instead of trusting the stored pointer
+        if troop := findTroopByID(game, t.ID); troop != nil {
+            return troop.Position, true
+        }
+    case *Building:
+        if t.Active {
+            return t.Position, true
+        }
+    }
+    return Position{}, false
+}
+
+// findTroopByID returns the active troop with the given ID, or nil
+func findTroopByID(game *Game, id int) *Troop {
+    for i := range game.Troops {
+        if game.Troops[i].ID == id && game.Troops[i].Active {
+            return &game.Troops[i]
+        }
+    }
+    return nil
+}
 
 // Add this to UpdateGame or gameloop.go to update projectiles
 func UpdateProjectiles(game *Game) {
@@ -277,110 +466,44 @@ func UpdateProjectiles(game *Game) {
 }
 
 
-// LoadProjectileTemplates loads projectile templates from a CSV file
-func LoadProjectileTemplates(filepath string) error {
-    // Initialize the map
-    ProjectileTemplateMap = make(map[string]*ProjectileTemplate)
-    
-    // Open the CSV file
-    file, err := os.Open(filepath)
-    if err != nil {
-        return fmt.Errorf("failed to open CSV file: %v", err)
-    }
-    defer file.Close()
-
-    // Parse the CSV file
-    reader := csv.NewReader(file)
-    
-    // Read the header
-    header, err := reader.Read()
-    if err != nil {
-        return fmt.Errorf("failed to read CSV header: %v", err)
-    }
-    
-    // Create a map to store column indices for easier access
-    columnMap := make(map[string]int)
-    for i, col := range header {
-        columnMap[col] = i
-    }
-    
-    // Skip type rows (if needed, similar to troops.csv)
-    _, err = reader.Read() // Skip type information row
-    if err != nil {
-        return fmt.Errorf("failed to read CSV types: %v", err)
-    }
-    
-    _, err = reader.Read() // Skip type descriptor row
+// LoadProjectileTemplates loads projectile templates from a CSV file in fsys
+func LoadProjectileTemplates(fsys fs.FS, path string) (map[string]*ProjectileTemplate, error) {
+    table, err := ReadCSVTable(fsys, path)
     if err != nil {
-        return fmt.Errorf("failed to read CSV type descriptors: %v", err)
+        return nil, err
     }
     
-    // Read all projectile data rows
-    rowCount := 0
-    loadedCount := 0
-    for {
-        record, err := reader.Read()
-        if err == io.EOF {
-            break
-        }
-        if err != nil {
-            fmt.Printf("Warning: failed to read CSV row %d: %v\n", rowCount+4, err)
-            continue
-        }
-        rowCount++
-        
+    projectiles := make(map[string]*ProjectileTemplate)
+    var errs []error
+    for i, record := range table.Rows {
         // Skip empty rows or rows with "NOTINUSE" in the name
-        if len(record) == 0 || record[0] == "" || strings.Contains(record[0], "NOTINUSE") {
+        if skipCSVRow(record) {
             continue
         }
         
-        // Extract projectile data from the record
-        projectileName := record[columnMap["Name"]]
-        if projectileName == "" {
-            continue // Skip rows without a name
-        }
-
-        // Create a new template
-        template := &ProjectileTemplate{
-            Name:                 projectileName,
-            Rarity:               getStringValue(record, columnMap, "Rarity"),
-            Speed:                getFloatValue(record, columnMap, "Speed") / 200, // Adjust divisor as needed
-            Scale:                getFloatValue(record, columnMap, "Scale") / 100,
-            Homing:               getBoolValue(record, columnMap, "Homing"),
-            HomingTime:           getFloatValue(record, columnMap, "HomingTime") / 10, // Adjust divisor as needed
-            HomingMinDistance:    getFloatValue(record, columnMap, "HomingMinDistance"),
-            Damage:               getIntValue(record, columnMap, "Damage"),
-            CrownTowerDamagePercent: getFloatValue(record, columnMap, "CrownTowerDamagePercent"),
-            Pushback:             getFloatValue(record, columnMap, "Pushback"),
-            PushbackAll:          getBoolValue(record, columnMap, "PushbackAll"),
-            Radius:               getFloatValue(record, columnMap, "Radius") / 1000,
-            AoeToAir:             getBoolValue(record, columnMap, "AoeToAir"),
-            AoeToGround:          getBoolValue(record, columnMap, "AoeToGround"),
-            OnlyEnemies:          getBoolValue(record, columnMap, "OnlyEnemies"),
-            MaximumTargets:       getIntValue(record, columnMap, "MaximumTargets"),
-            ProjectileRadius:     getFloatValue(record, columnMap, "ProjectileRadius") / 1000,
-            TrailEffect:          getStringValue(record, columnMap, "TrailEffect"),
-            ConstantHeight:       getBoolValue(record, columnMap, "ConstantHeight"),
+        template := &ProjectileTemplate{}
+        if err := table.Unmarshal(i, template); err != nil {
+            errs = append(errs, err)
+            continue
         }
-        
-        // Add the template to the map
-        ProjectileTemplateMap[projectileName] = template
-        loadedCount++
-        
+        projectiles[template.Name] = template
+    }
+    if len(errs) > 0 {
+        return nil, errors.Join(errs...)
     }
     
     // Check if we loaded any templates
-    if loadedCount == 0 {
-        return fmt.Errorf("no valid projectile templates found in CSV")
+    if len(projectiles) == 0 {
+        return nil, fmt.Errorf("no valid projectile templates found in CSV")
     }
     
-    return nil
+    return projectiles, nil
 }
 
 // Replace the linkTroopToProjectile function in projectile.go with this improved version
-func linkTroopToProjectile(troopTemplate *TroopTemplate, projectileName string) {
+func linkTroopToProjectile(troopTemplate *TroopTemplate, projectileName string, projectiles map[string]*ProjectileTemplate) {
     // Determine if this troop should be melee based on range
-    isMeleeTroop := troopTemplate.Range <= 1.0
+    isMeleeTroop := IsMeleeRange(troopTemplate.Range)
     
     // If no projectile specified (melee troop) OR this is a melee troop, leave it without a projectile
     if projectileName == "" || isMeleeTroop {
@@ -389,30 +512,17 @@ func linkTroopToProjectile(troopTemplate *TroopTemplate, projectileName string)
         return
     }
     
-    // Check if projectile template map is initialized
-    if ProjectileTemplateMap == nil {
-        // Projectile system not initialized yet, call the initialization
-        InitializeProjectileSystem()
-    }
-    
     // Look up the projectile template
-    if projectileTemplate, exists := ProjectileTemplateMap[projectileName]; exists {
+    if projectileTemplate, exists := projectiles[projectileName]; exists {
         // Deep copy the projectile template to avoid sharing references
         troopTemplate.Projectile = *projectileTemplate
-    } else {
-        // Only assign default arrow if this is definitely a ranged troop
-        if !isMeleeTroop {
-            if defTemplate, exists := ProjectileTemplateMap["arrow"]; exists {
-                troopTemplate.Projectile = *defTemplate
-                // Update name to match the requested projectile
-                troopTemplate.Projectile.Name = projectileName
-                fmt.Printf("Using default arrow for ranged troop %s (requested %s)\n", troopTemplate.Name, projectileName)
-            }else {
-            // This is a melee troop, don't assign a projectile
-            troopTemplate.Projectile.Name = ""
-            fmt.Printf("Melee troop %s will not use projectiles\n", troopTemplate.Name)
-        }}
+        return
     }
+    
+    // Without its projectile the troop hits like a melee unit; the validate
+    // command reports the broken reference
+    troopTemplate.Projectile.Name = ""
+    logf("Warning: troop %s uses unknown projectile %s\n", troopTemplate.Name, projectileName)
 }
 
 // This function should be added to troop_wrapper.go to handle projectile firing
@@ -423,17 +533,19 @@ func (et *ExtendedTroop) FireProjectile(game *Game, target Position) {
 	}
 	
 	// Create a new projectile based on the troop's template
-	projectile := CreateProjectile(
-		et.Template.Projectile.Name,
+	projectile := NewProjectile(
+		&et.Template.Projectile,
 		et.Position,
 		target,
-		et.Damage,
+		ShotDamage(&et.Template.Projectile, et.Damage, LevelMultiplier(et.Template.Rarity, et.Level)),
 		et.Team,
 		et.ID,
 	)
 	
 	// Add the projectile to the game
 	if projectile != nil {
+		projectile.Card = et.Card
+		projectile.Owner = et.Owner
 		game.Projectiles = append(game.Projectiles, *projectile)
 	}
 }
\ No newline at end of file
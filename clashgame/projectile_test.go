@@ -0,0 +1,90 @@
+// projectile_test.go
+package clashgame
+
+import "testing"
+
+// TestSingleTargetShotHitsOneTroop fires a non-homing arrow into a tight
+// group of Knights. It must only hurt the Knight it was aimed at, and after
+// that Knight is gone at most the one Knight it lands on.
+func TestSingleTargetShotHitsOneTroop(t *testing.T) {
+	game := NewGame(testCatalog(t), nil, DefaultGameConfig())
+	center := game.Grid.CellToPosition(game.Grid.Columns/2, game.Grid.Rows/2+6)
+	var knights []int
+	for i := 0; i < 3; i++ {
+		pos := Position{X: center.X + float64(i-1)*game.Grid.CellWidth/2, Y: center.Y}
+		knights = append(knights, spawnLanded(t, game, "Knight", pos, 0))
+	}
+
+	arrow, exists := game.Catalog.Projectile("ArcherArrow")
+	if !exists {
+		t.Fatal("no ArcherArrow in the embedded data")
+	}
+	// Like the Firecracker's shot: not homing, no radius, but flagged to
+	// hit ground units
+	template := *arrow
+	template.Homing = false
+	template.Radius = 0
+	template.AoeToGround = true
+
+	hurt := func() int {
+		count := 0
+		for _, i := range knights {
+			if game.Troops[i].Health < game.Troops[i].MaxHealth {
+				count++
+			}
+		}
+		return count
+	}
+
+	target := &game.Troops[knights[1]]
+	shot := NewProjectile(&template, Position{X: center.X, Y: 0}, target.Position, 100, 1, 0)
+	shot.TargetEntity = target
+	shot.HandleImpact(game, target.Position)
+	if target.Health == target.MaxHealth {
+		t.Error("the shot missed its target")
+	}
+	if count := hurt(); count != 1 {
+		t.Errorf("the shot hurt %d Knights, want only its target", count)
+	}
+
+	for _, i := range knights {
+		game.Troops[i].Health = game.Troops[i].MaxHealth
+	}
+	target.Active = false
+	shot.HandleImpact(game, target.Position)
+	if count := hurt(); count > 1 {
+		t.Errorf("a shot whose target is gone hurt %d Knights, want at most one", count)
+	}
+}
+
+// TestCrownTowerDamageReduction checks a Fireball only does its reduced
+// crown tower damage to towers, and full damage to a placed Cannon
+func TestCrownTowerDamageReduction(t *testing.T) {
+	game, cmd := cannonGame(t)
+	applyCommand(game, cmd)
+	cannon := game.DeployedBuildings[0]
+	tower := &game.Teams[cannon.Team].Buildings[0]
+
+	fireball, exists := game.Catalog.Projectile("FireballSpell")
+	if !exists || fireball.CrownTowerDamagePercent == 0 {
+		t.Fatal("no FireballSpell with a crown tower reduction in the embedded data")
+	}
+	shot := NewProjectile(fireball, Position{}, cannon.Position, 0, EnemyTeam(cannon.Team), 0)
+	damage := shot.Damage
+
+	for _, building := range []*Building{cannon, tower} {
+		before := building.Health
+		shot.damageBuilding(game, building)
+		got := before - building.Health
+		want := damage
+		if building.IsCrownTower() {
+			want = int(float64(damage) * (100 + fireball.CrownTowerDamagePercent) / 100)
+		}
+		if got != want {
+			t.Errorf("Fireball did %d damage to %q, want %d", got, building.Name, want)
+		}
+	}
+	if cannon.IsCrownTower() || !tower.IsCrownTower() {
+		t.Error("IsCrownTower mixes up the Cannon and the princess tower")
+	}
+}
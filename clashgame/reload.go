@@ -0,0 +1,104 @@
+// reload.go
+package clashgame
+
+import (
+	"io/fs"
+	"time"
+)
+
+// QueueCatalog hands the game a new catalog, for example after a balance
+// edit. It is swapped in at the start of the next tick, so no step of the
+// simulation sees two catalogs. Troops and projectiles already on the field
+// keep the templates they were created from; only new deploys use the new
+// stats. Safe to call from any goroutine.
+func (g *Game) QueueCatalog(catalog *Catalog) {
+	g.nextCatalog.Store(catalog)
+}
+
+// applyQueuedCatalog swaps in the catalog from QueueCatalog, if any. Must be
+// called with the game lock held.
+func (g *Game) applyQueuedCatalog() {
+	catalog := g.nextCatalog.Swap(nil)
+	if catalog == nil {
+		return
+	}
+	g.Catalog = catalog
+	if g.TroopSelection != nil {
+		g.TroopSelection.SetCatalog(catalog)
+	}
+	logf("Catalog reloaded: %d troops, %d cards\n", catalog.NumTroops(), len(catalog.CardNames()))
+}
+
+// CatalogWatcher polls the template CSVs of a data FS and queues a reloaded
+// catalog on a game whenever one of them changes
+type CatalogWatcher struct {
+	fsys     fs.FS
+	interval time.Duration
+	stamps   [3]fileStamp
+	stop     chan struct{}
+}
+
+// fileStamp is what the watcher compares to spot an edited file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// WatchCatalog starts polling the CSVs in fsys every interval and reloads
+// them into the game when they change. A reload that fails, for example
+// because of a parse error, is logged and leaves the current catalog in
+// place. Only an external data directory can change; see OpenData.
+func WatchCatalog(game *Game, fsys fs.FS, interval time.Duration) *CatalogWatcher {
+	w := &CatalogWatcher{
+		fsys:     fsys,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	w.changed()
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if !w.changed() {
+					continue
+				}
+				catalog, err := ReloadCatalog(w.fsys)
+				if err != nil {
+					logf("Catalog reload failed, keeping the current data: %v\n", err)
+					continue
+				}
+				game.QueueCatalog(catalog)
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+	return w
+}
+
+// Stop ends the polling
+func (w *CatalogWatcher) Stop() {
+	close(w.stop)
+}
+
+// changed records the current state of the files and reports whether any of
+// them differs from the last poll. Files that can't be read count as
+// unchanged; the editor may be halfway through saving them.
+func (w *CatalogWatcher) changed() bool {
+	changed := false
+	for i, path := range []string{TroopsCSVPath, ProjectilesCSVPath, BuildingsCSVPath} {
+		info, err := fs.Stat(w.fsys, path)
+		if err != nil {
+			continue
+		}
+		stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+		if stamp != w.stamps[i] {
+			w.stamps[i] = stamp
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,83 @@
+// sim.go
+package clashgame
+
+// SimEntrant is one side of a headless match: a bot and the deck it plays
+type SimEntrant struct {
+	Name          string
+	Difficulty    BotDifficulty
+	Deck          []string // Card names, played at the tournament level; empty uses DefaultBotDeck
+	Deterministic bool     // Play NewDeterministicBot bots, which ignore the seed
+}
+
+// SimResult is the outcome of one headless match
+type SimResult struct {
+	Winner       int // Winning team, or NoWinner for a draw
+	Crowns       [2]int
+	Ticks        int
+	DamageByCard [2]map[string]int // Per team
+	CommandLog   []Command         // Every command the bots played, see Game.CommandLog
+}
+
+// SimulateMatch plays two bots against each other without a window, as fast
+// as the simulation runs, until the config's game mode ends the match or
+// maxTicks have passed (0 plays the mode's full length). A match cut short
+// goes to the team with more crowns. With a TeamSize above 1 every player of
+// a team is a bot of that team's entrant. The same seed always plays out the
+// same match, decks of draft and random modes included. Matches only share
+// the catalog and arena, which they don't modify, so several can run in
+// parallel.
+func SimulateMatch(catalog *Catalog, arena *Arena, config GameConfig, entrants [2]SimEntrant, seed int64, maxTicks int) SimResult {
+	profiles := make([]PlayerProfile, NumTeams*max(config.TeamSize, 1))
+	for i := range profiles {
+		profiles[i] = DefaultPlayerProfile()
+		for _, name := range entrants[PlayerTeam(i)].Deck {
+			profiles[i].Deck = append(profiles[i].Deck, DeckCard{Name: name, Level: TournamentLevelCap})
+		}
+	}
+
+	config.Seed = seed
+	game := NewGame(catalog, arena, config, profiles...)
+	for i := range game.Players {
+		// Each player gets their own stream so no bot's choices shift another's
+		entrant := entrants[PlayerTeam(i)]
+		if entrant.Deterministic {
+			game.AddBot(NewDeterministicBot(i, entrant.Difficulty))
+			continue
+		}
+		game.AddBot(NewBot(i, entrant.Difficulty, seed*int64(len(game.Players))+int64(i)))
+	}
+	game.Running = true
+
+	if maxTicks <= 0 {
+		maxTicks = config.Mode.LengthTicks()
+	}
+	for game.GameTime < maxTicks && game.Phase != PhaseEnded {
+		game.Tick()
+	}
+
+	result := SimResult{
+		Winner:     game.Winner,
+		Ticks:      game.GameTime,
+		CommandLog: game.CommandLog,
+	}
+	for team := range game.Teams {
+		result.Crowns[team] = Crowns(game, team)
+	}
+	// Teammates' damage is added up per card
+	for i, damage := range game.DamageByCard {
+		team := game.Players[i].Team
+		for card, amount := range damage {
+			if result.DamageByCard[team] == nil {
+				result.DamageByCard[team] = make(map[string]int)
+			}
+			result.DamageByCard[team][card] += amount
+		}
+	}
+	if result.Winner == NoWinner && result.Crowns[0] != result.Crowns[1] {
+		result.Winner = 0
+		if result.Crowns[1] > result.Crowns[0] {
+			result.Winner = 1
+		}
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+// sim_test.go
+package clashgame
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDeterministicBotsReplay plays the same match of deterministic bots
+// twice. Every command and the result must come out the same.
+func TestDeterministicBotsReplay(t *testing.T) {
+	catalog := testCatalog(t)
+	entrants := [2]SimEntrant{
+		{Name: "hard", Difficulty: BotHard, Deterministic: true},
+		{Name: "medium", Difficulty: BotMedium, Deterministic: true},
+	}
+	maxTicks := SecondsToTicks(90)
+
+	first := SimulateMatch(catalog, nil, DefaultGameConfig(), entrants, 1, maxTicks)
+	second := SimulateMatch(catalog, nil, DefaultGameConfig(), entrants, 1, maxTicks)
+
+	if len(first.CommandLog) == 0 {
+		t.Fatal("the bots never played a card")
+	}
+	if !reflect.DeepEqual(first.CommandLog, second.CommandLog) {
+		t.Errorf("command logs differ: %d and %d commands", len(first.CommandLog), len(second.CommandLog))
+	}
+	if first.Winner != second.Winner || first.Crowns != second.Crowns || first.Ticks != second.Ticks {
+		t.Errorf("results differ: %+v and %+v", first, second)
+	}
+
+	// Deterministic bots ignore the seed
+	other := SimulateMatch(catalog, nil, DefaultGameConfig(), entrants, 2, maxTicks)
+	if !reflect.DeepEqual(first.CommandLog, other.CommandLog) {
+		t.Error("the seed changed what deterministic bots played")
+	}
+}
@@ -0,0 +1,465 @@
+// snapshot.go
+package clashgame
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Snapshot precision. Values are rounded when a snapshot is taken, so every
+// codec round-trips a snapshot exactly.
+const (
+	snapshotPositionScale = 16  // Positions are kept in 1/16 pixel steps
+	snapshotElixirScale   = 100 // Elixir is kept in 1/100 steps
+)
+
+// NoBaseTick marks a delta that carries the full state instead of changes
+// to an earlier snapshot
+const NoBaseTick = -1
+
+// TroopStatus is what a troop is doing, so clients can pick an animation
+type TroopStatus uint8
+
+const (
+	TroopDeploying TroopStatus = iota // Landing, can't act yet
+	TroopSeeking                      // Walking toward a target
+	TroopAttacking                    // Attacking a building
+	TroopFighting                     // Attacking another troop
+)
+
+// Snapshot is the state clients need to draw a tick of the match
+type Snapshot struct {
+	Tick        int               `json:"tick"`
+	Phase       MatchPhase        `json:"phase"`
+	Winner      int               `json:"winner"`
+	Players     []PlayerState     `json:"players"`
+	Troops      []TroopState      `json:"troops"`    // Sorted by ID
+	Buildings   []BuildingState   `json:"buildings"` // Sorted by ID
+	Projectiles []ProjectileState `json:"projectiles"`
+}
+
+// PlayerState is a player's part of a snapshot. Hand is only filled in for
+// the client playing the seat, see Snapshot.ForPlayer.
+type PlayerState struct {
+	Elixir float64  `json:"elixir"`
+	Hand   []string `json:"hand,omitempty"`
+}
+
+// TroopState is a troop in a snapshot. The template name tells clients how
+// to draw it.
+type TroopState struct {
+	ID        int         `json:"id"`
+	Template  string      `json:"template"`
+	Team      int         `json:"team"`
+	X         float64     `json:"x"`
+	Y         float64     `json:"y"`
+	Health    int         `json:"health"`
+	MaxHealth int         `json:"maxHealth"`
+	Status    TroopStatus `json:"status"`
+}
+
+// BuildingState is a standing crown tower or deployed building in a
+// snapshot. Crown towers have no template.
+type BuildingState struct {
+	ID        int     `json:"id"`
+	Template  string  `json:"template"`
+	Team      int     `json:"team"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Health    int     `json:"health"`
+	MaxHealth int     `json:"maxHealth"`
+}
+
+// ProjectileState is a projectile in flight. Projectiles live for a few
+// ticks and move on every one, so deltas always carry all of them.
+type ProjectileState struct {
+	Template string  `json:"template"`
+	Team     int     `json:"team"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+}
+
+// Snapshot captures the current state of the match. It takes the game
+// lock, so callers must not already hold it.
+func (g *Game) Snapshot() *Snapshot {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	snapshot := &Snapshot{
+		Tick:   g.GameTime,
+		Phase:  g.Phase,
+		Winner: g.Winner,
+	}
+	for _, player := range g.Players {
+		snapshot.Players = append(snapshot.Players, PlayerState{
+			Elixir: quantize(player.Elixir, snapshotElixirScale),
+			Hand:   append([]string(nil), player.Hand...),
+		})
+	}
+	for i := range g.Troops {
+		troop := &g.Troops[i]
+		if !troop.Active {
+			continue
+		}
+		snapshot.Troops = append(snapshot.Troops, TroopState{
+			ID:        troop.ID,
+			Template:  troop.Name,
+			Team:      troop.Team,
+			X:         quantize(troop.Position.X, snapshotPositionScale),
+			Y:         quantize(troop.Position.Y, snapshotPositionScale),
+			Health:    troop.Health,
+			MaxHealth: troop.MaxHealth,
+			Status:    troopStatus(troop),
+		})
+	}
+	for _, building := range g.BuildingMap {
+		if !building.Active {
+			continue
+		}
+		snapshot.Buildings = append(snapshot.Buildings, BuildingState{
+			ID:        building.ID,
+			Template:  building.Name,
+			Team:      building.Team,
+			X:         quantize(building.Position.X, snapshotPositionScale),
+			Y:         quantize(building.Position.Y, snapshotPositionScale),
+			Health:    building.Health,
+			MaxHealth: building.MaxHealth,
+		})
+	}
+	for _, projectile := range g.Projectiles {
+		if !projectile.Active {
+			continue
+		}
+		snapshot.Projectiles = append(snapshot.Projectiles, ProjectileState{
+			Template: projectile.Name,
+			Team:     projectile.Team,
+			X:        quantize(projectile.Position.X, snapshotPositionScale),
+			Y:        quantize(projectile.Position.Y, snapshotPositionScale),
+		})
+	}
+
+	sort.Slice(snapshot.Troops, func(i, j int) bool { return snapshot.Troops[i].ID < snapshot.Troops[j].ID })
+	sort.Slice(snapshot.Buildings, func(i, j int) bool { return snapshot.Buildings[i].ID < snapshot.Buildings[j].ID })
+	return snapshot
+}
+
+// ForPlayer returns the snapshot as the player in a seat may see it: without
+// the other players' hands. The snapshot itself is left untouched.
+func (s *Snapshot) ForPlayer(player int) *Snapshot {
+	view := *s
+	view.Players = make([]PlayerState, len(s.Players))
+	for i, state := range s.Players {
+		if i != player {
+			state.Hand = nil
+		}
+		view.Players[i] = state
+	}
+	return &view
+}
+
+// troopStatus reads what a troop is doing from its combat state
+func troopStatus(troop *Troop) TroopStatus {
+	switch {
+	case troop.IsDeploying():
+		return TroopDeploying
+	case troop.IsAttacking && troop.TargetTroopID != 0:
+		return TroopFighting
+	case troop.IsAttacking:
+		return TroopAttacking
+	default:
+		return TroopSeeking
+	}
+}
+
+// quantize rounds a value to 1/scale steps
+func quantize(value, scale float64) float64 {
+	return math.Round(value*scale) / scale
+}
+
+// DeltaFields flags the fields of a troop or building a delta carries
+type DeltaFields uint8
+
+const (
+	FieldIdentity  DeltaFields = 1 << iota // Template and team, only sent for new entities
+	FieldPosition                          // X and Y
+	FieldHealth                            // Health
+	FieldMaxHealth                         // MaxHealth
+	FieldStatus                            // Status, troops only
+
+	troopFields    = FieldIdentity | FieldPosition | FieldHealth | FieldMaxHealth | FieldStatus
+	buildingFields = FieldIdentity | FieldPosition | FieldHealth | FieldMaxHealth
+)
+
+// TroopDelta is a new or changed troop. Only the flagged fields of State
+// are meaningful.
+type TroopDelta struct {
+	Fields DeltaFields `json:"fields"`
+	State  TroopState  `json:"state"`
+}
+
+// BuildingDelta is a new or changed building. Only the flagged fields of
+// State are meaningful.
+type BuildingDelta struct {
+	Fields DeltaFields   `json:"fields"`
+	State  BuildingState `json:"state"`
+}
+
+// SnapshotDelta turns the snapshot at BaseTick into the one at Tick. With
+// BaseTick set to NoBaseTick it holds the full snapshot, as a keyframe.
+type SnapshotDelta struct {
+	BaseTick         int               `json:"baseTick"`
+	Tick             int               `json:"tick"`
+	Phase            MatchPhase        `json:"phase"`
+	Winner           int               `json:"winner"`
+	Players          []PlayerState     `json:"players"`
+	Troops           []TroopDelta      `json:"troops"`           // Sorted by ID
+	RemovedTroops    []int             `json:"removedTroops"`    // Sorted
+	Buildings        []BuildingDelta   `json:"buildings"`        // Sorted by ID
+	RemovedBuildings []int             `json:"removedBuildings"` // Sorted
+	Projectiles      []ProjectileState `json:"projectiles"`
+}
+
+// Diff describes how to get from base to next. A nil base makes a keyframe.
+// Both snapshots must have their troops and buildings sorted by ID, as
+// Game.Snapshot leaves them.
+func Diff(base, next *Snapshot) *SnapshotDelta {
+	delta := &SnapshotDelta{
+		BaseTick:    NoBaseTick,
+		Tick:        next.Tick,
+		Phase:       next.Phase,
+		Winner:      next.Winner,
+		Players:     append([]PlayerState(nil), next.Players...),
+		Projectiles: append([]ProjectileState(nil), next.Projectiles...),
+	}
+	var baseTroops []TroopState
+	var baseBuildings []BuildingState
+	if base != nil {
+		delta.BaseTick = base.Tick
+		baseTroops, baseBuildings = base.Troops, base.Buildings
+	}
+
+	// Walk both sorted lists side by side
+	i, j := 0, 0
+	for i < len(baseTroops) || j < len(next.Troops) {
+		switch {
+		case j == len(next.Troops) || (i < len(baseTroops) && baseTroops[i].ID < next.Troops[j].ID):
+			delta.RemovedTroops = append(delta.RemovedTroops, baseTroops[i].ID)
+			i++
+		case i == len(baseTroops) || next.Troops[j].ID < baseTroops[i].ID:
+			delta.Troops = append(delta.Troops, TroopDelta{Fields: troopFields, State: next.Troops[j]})
+			j++
+		default:
+			if fields := troopChanges(baseTroops[i], next.Troops[j]); fields != 0 {
+				delta.Troops = append(delta.Troops, TroopDelta{Fields: fields, State: next.Troops[j]})
+			}
+			i++
+			j++
+		}
+	}
+
+	i, j = 0, 0
+	for i < len(baseBuildings) || j < len(next.Buildings) {
+		switch {
+		case j == len(next.Buildings) || (i < len(baseBuildings) && baseBuildings[i].ID < next.Buildings[j].ID):
+			delta.RemovedBuildings = append(delta.RemovedBuildings, baseBuildings[i].ID)
+			i++
+		case i == len(baseBuildings) || next.Buildings[j].ID < baseBuildings[i].ID:
+			delta.Buildings = append(delta.Buildings, BuildingDelta{Fields: buildingFields, State: next.Buildings[j]})
+			j++
+		default:
+			if fields := buildingChanges(baseBuildings[i], next.Buildings[j]); fields != 0 {
+				delta.Buildings = append(delta.Buildings, BuildingDelta{Fields: fields, State: next.Buildings[j]})
+			}
+			i++
+			j++
+		}
+	}
+	return delta
+}
+
+// troopChanges flags the fields that differ between two states of a troop.
+// A troop whose template or team changed is sent again in full.
+func troopChanges(before, after TroopState) DeltaFields {
+	if before.Template != after.Template || before.Team != after.Team {
+		return troopFields
+	}
+	var fields DeltaFields
+	if before.X != after.X || before.Y != after.Y {
+		fields |= FieldPosition
+	}
+	if before.Health != after.Health {
+		fields |= FieldHealth
+	}
+	if before.MaxHealth != after.MaxHealth {
+		fields |= FieldMaxHealth
+	}
+	if before.Status != after.Status {
+		fields |= FieldStatus
+	}
+	return fields
+}
+
+// buildingChanges flags the fields that differ between two states of a
+// building
+func buildingChanges(before, after BuildingState) DeltaFields {
+	if before.Template != after.Template || before.Team != after.Team {
+		return buildingFields
+	}
+	var fields DeltaFields
+	if before.X != after.X || before.Y != after.Y {
+		fields |= FieldPosition
+	}
+	if before.Health != after.Health {
+		fields |= FieldHealth
+	}
+	if before.MaxHealth != after.MaxHealth {
+		fields |= FieldMaxHealth
+	}
+	return fields
+}
+
+// Apply rebuilds the snapshot a delta describes from its base snapshot,
+// which must be the one at BaseTick (nil for a keyframe). The base is left
+// untouched.
+func (d *SnapshotDelta) Apply(base *Snapshot) (*Snapshot, error) {
+	if d.BaseTick == NoBaseTick {
+		base = &Snapshot{}
+	} else if base == nil || base.Tick != d.BaseTick {
+		return nil, fmt.Errorf("delta for tick %d needs the snapshot of tick %d", d.Tick, d.BaseTick)
+	}
+
+	snapshot := &Snapshot{
+		Tick:        d.Tick,
+		Phase:       d.Phase,
+		Winner:      d.Winner,
+		Players:     append([]PlayerState(nil), d.Players...),
+		Projectiles: append([]ProjectileState(nil), d.Projectiles...),
+	}
+
+	troops := make(map[int]TroopState, len(base.Troops))
+	for _, troop := range base.Troops {
+		troops[troop.ID] = troop
+	}
+	for _, id := range d.RemovedTroops {
+		if _, exists := troops[id]; !exists {
+			return nil, fmt.Errorf("delta removes unknown troop %d", id)
+		}
+		delete(troops, id)
+	}
+	for _, change := range d.Troops {
+		troop, exists := troops[change.State.ID]
+		if !exists && change.Fields&FieldIdentity == 0 {
+			return nil, fmt.Errorf("delta changes unknown troop %d", change.State.ID)
+		}
+		troops[change.State.ID] = mergeTroop(troop, change)
+	}
+
+	buildings := make(map[int]BuildingState, len(base.Buildings))
+	for _, building := range base.Buildings {
+		buildings[building.ID] = building
+	}
+	for _, id := range d.RemovedBuildings {
+		if _, exists := buildings[id]; !exists {
+			return nil, fmt.Errorf("delta removes unknown building %d", id)
+		}
+		delete(buildings, id)
+	}
+	for _, change := range d.Buildings {
+		building, exists := buildings[change.State.ID]
+		if !exists && change.Fields&FieldIdentity == 0 {
+			return nil, fmt.Errorf("delta changes unknown building %d", change.State.ID)
+		}
+		buildings[change.State.ID] = mergeBuilding(building, change)
+	}
+
+	for _, troop := range troops {
+		snapshot.Troops = append(snapshot.Troops, troop)
+	}
+	for _, building := range buildings {
+		snapshot.Buildings = append(snapshot.Buildings, building)
+	}
+	sort.Slice(snapshot.Troops, func(i, j int) bool { return snapshot.Troops[i].ID < snapshot.Troops[j].ID })
+	sort.Slice(snapshot.Buildings, func(i, j int) bool { return snapshot.Buildings[i].ID < snapshot.Buildings[j].ID })
+	return snapshot, nil
+}
+
+// mergeTroop copies the flagged fields of a delta onto a troop
+func mergeTroop(troop TroopState, change TroopDelta) TroopState {
+	troop.ID = change.State.ID
+	if change.Fields&FieldIdentity != 0 {
+		troop.Template, troop.Team = change.State.Template, change.State.Team
+	}
+	if change.Fields&FieldPosition != 0 {
+		troop.X, troop.Y = change.State.X, change.State.Y
+	}
+	if change.Fields&FieldHealth != 0 {
+		troop.Health = change.State.Health
+	}
+	if change.Fields&FieldMaxHealth != 0 {
+		troop.MaxHealth = change.State.MaxHealth
+	}
+	if change.Fields&FieldStatus != 0 {
+		troop.Status = change.State.Status
+	}
+	return troop
+}
+
+// mergeBuilding copies the flagged fields of a delta onto a building
+func mergeBuilding(building BuildingState, change BuildingDelta) BuildingState {
+	building.ID = change.State.ID
+	if change.Fields&FieldIdentity != 0 {
+		building.Template, building.Team = change.State.Template, change.State.Team
+	}
+	if change.Fields&FieldPosition != 0 {
+		building.X, building.Y = change.State.X, change.State.Y
+	}
+	if change.Fields&FieldHealth != 0 {
+		building.Health = change.State.Health
+	}
+	if change.Fields&FieldMaxHealth != 0 {
+		building.MaxHealth = change.State.MaxHealth
+	}
+	return building
+}
+
+// SnapshotStream makes the deltas sent to one client. Every delta is
+// against the newest snapshot the client acknowledged, so a lost update only
+// makes the following ones larger. Until the client acknowledges a snapshot
+// still in the history it gets keyframes.
+type SnapshotStream struct {
+	history []*Snapshot // Sent snapshots newer than the acknowledged one, oldest first
+	size    int
+	acked   *Snapshot
+}
+
+// NewSnapshotStream creates a stream that remembers up to size unacknowledged
+// snapshots, for example one second's worth
+func NewSnapshotStream(size int) *SnapshotStream {
+	return &SnapshotStream{size: max(size, 1)}
+}
+
+// Next returns the delta that brings the client to the snapshot
+func (s *SnapshotStream) Next(snapshot *Snapshot) *SnapshotDelta {
+	delta := Diff(s.acked, snapshot)
+	s.history = append(s.history, snapshot)
+	if len(s.history) > s.size {
+		// The client fell too far behind to catch up from its acknowledged
+		// snapshot; the next deltas are keyframes until it acknowledges one
+		s.history = s.history[1:]
+		s.acked = nil
+	}
+	return delta
+}
+
+// Ack records that the client has the snapshot of a tick. Acks of unknown or
+// older ticks are ignored, so they may arrive late or out of order.
+func (s *SnapshotStream) Ack(tick int) {
+	for i, snapshot := range s.history {
+		if snapshot.Tick == tick {
+			s.acked = snapshot
+			s.history = s.history[i+1:]
+			return
+		}
+	}
+}
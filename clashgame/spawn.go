@@ -7,14 +7,21 @@ import (
 	"github.com/google/uuid"
 )
 
+// buildingCenter returns where a building of the given width placed on a
+// cell position is centered. Even-width footprints straddle the cell's
+// corner, so they are shifted half a cell.
+func buildingCenter(pos Position, widthCells float64, grid *GridSystem) Position {
+    if (int(widthCells) % 2) == 0 {
+        pos.X += grid.CellWidth / 2
+        pos.Y += grid.CellWidth / 2
+    }
+    return pos
+}
+
 // NewBuilding creates a new Building instance
 func NewBuilding(x, y float64, health, damage int, attackRangeInCells float64, clr color.RGBA, widthCells, heightCells float64, grid *GridSystem) Building {
-    if (int(widthCells) % 2) == 0{
-        x += grid.CellWidth / 2
-        y += grid.CellWidth / 2
-    }
     return Building{
-        Position:      Position{X: x, Y: y},
+        Position:      buildingCenter(Position{X: x, Y: y}, widthCells, grid),
         Health:        health,
         MaxHealth:     health,
         Damage:        damage,
@@ -25,15 +32,36 @@ func NewBuilding(x, y float64, health, damage int, attackRangeInCells float64, c
         Active:        true,
         ProjectileType: "normal",
         LastAttack:    0,
+        DamageMultiplier: 1,
     }
 }
 
 
-// NewKingBuilding creates a new king Building instance
-func NewKingBuilding(x, y float64, clr color.RGBA, grid *GridSystem) KingBuilding {
+// Crown tower stats at king level 1; NewTeam scales them by king level
+const (
+    kingTowerHitpoints     = 2000
+    kingTowerDamage        = 50
+    princessTowerHitpoints = 1200
+    princessTowerDamage    = 30
+)
+
+// Crown tower reach in tiles and seconds between shots
+const (
+    kingTowerRange        = 7.0
+    kingTowerHitSpeed     = 1.0
+    princessTowerRange    = 7.5
+    princessTowerHitSpeed = 0.8
+)
+
+// NewKingBuilding creates a new king Building instance scaled to the king level
+func NewKingBuilding(x, y float64, clr color.RGBA, kingLevel int, grid *GridSystem) KingBuilding {
+    multiplier := TowerLevelMultiplier(kingLevel)
     
+    building := NewBuilding(x, y, ScaleStat(kingTowerHitpoints, multiplier), ScaleStat(kingTowerDamage, multiplier), TilesToCells(kingTowerRange), clr, kingBuildingWidth, kingBuildingHeight, grid)
+    building.AttackDelay = SecondsToTicks(kingTowerHitSpeed)
+    building.DamageMultiplier = multiplier
     return KingBuilding{
-        Building: NewBuilding(x, y, 2000, 50, 10, clr, kingBuildingWidth, kingBuildingHeight, grid),
+        Building: building,
         ActivatesEndgame: true,
     }
 }
@@ -44,45 +72,47 @@ func (b *Building) GetPixelDimensions(grid *GridSystem) (width, height float64)
     return
 }
 
-func NewPlayer(color color.RGBA, isTopPlayer bool, playerIndex int, grid *GridSystem) Player {
+// NewTeam creates a side's crown towers where the arena puts them. Tower
+// stats follow the given king level.
+func NewTeam(color color.RGBA, towers TowerLayout, kingLevel int, grid *GridSystem) Team {
+    team := Team{Color: color}
+    
+    // Princess towers scale with the king level too
+    towerMultiplier := TowerLevelMultiplier(kingLevel)
+    princessHitpoints := ScaleStat(princessTowerHitpoints, towerMultiplier)
+    princessDamage := ScaleStat(princessTowerDamage, towerMultiplier)
+    
+    kingPos := grid.CellToPosition(towers.King.Col, towers.King.Row)
+    team.KingBuilding = NewKingBuilding(kingPos.X, kingPos.Y, color, kingLevel, grid)
+    for _, cell := range towers.Princesses {
+        pos := grid.CellToPosition(cell.Col, cell.Row)
+        princess := NewBuilding(pos.X, pos.Y, princessHitpoints, princessDamage, TilesToCells(princessTowerRange), color, princessWidth, princessHeight, grid)
+        princess.AttackDelay = SecondsToTicks(princessTowerHitSpeed)
+        princess.DamageMultiplier = towerMultiplier
+        team.Buildings = append(team.Buildings, princess)
+    }
+    
+    return team
+}
+
+// NewPlayer creates a player on a team from their profile; the deck decides
+// card levels and the config sets the elixir
+func NewPlayer(color color.RGBA, team int, profile PlayerProfile, config GameConfig) Player {
     id, _ := uuid.NewRandom()
     
     // Initialize player with new attributes
     player := Player{
         Id:            id,
-        Elixir:        4.0,
+        Team:          team,
+        Elixir:        config.StartingElixir,
         Color:         color,
         NextCard:      0,
-        ElixirMax:     10,
-        ElixirGenRate: 0.1,
-    }
-    
-    // Position Buildings based on player position using grid cells
-    if isTopPlayer {
-        // Top player
-        kingPos := grid.CellToPosition(17, 5)  // Was 18,6
-        
-        leftBuildingPos := grid.CellToPosition(6, 12)   // Was 7,13
-        rightBuildingPos := grid.CellToPosition(28, 12) // Was 29,13
-        
-        player.KingBuilding = NewKingBuilding(kingPos.X, kingPos.Y, color, grid)
-        player.Buildings = []Building{
-            NewBuilding(leftBuildingPos.X, leftBuildingPos.Y, 1200, 30, 3.5, color, princessWidth, princessHeight, grid),
-            NewBuilding(rightBuildingPos.X, rightBuildingPos.Y, 1200, 30, 3.5, color, princessWidth, princessHeight, grid),
-        }
-    } else {
-        // Bottom player
-        kingPos := grid.CellToPosition(17, 57) // Was 18,58
-        
-        leftBuildingPos := grid.CellToPosition(6, 50)   // Was 7,51
-        rightBuildingPos := grid.CellToPosition(28, 50) // Was 29,51
-        
-        player.KingBuilding = NewKingBuilding(kingPos.X, kingPos.Y, color, grid)
-        player.Buildings = []Building{
-            NewBuilding(leftBuildingPos.X, leftBuildingPos.Y, 1200, 30, 3.5, color, princessWidth, princessHeight, grid),
-            NewBuilding(rightBuildingPos.X, rightBuildingPos.Y, 1200, 30, 3.5, color, princessWidth, princessHeight, grid),
-        }
+        ElixirMax:     config.MaxElixir,
+        ElixirGenRate: config.ElixirGenRate,
+        KingLevel:     profile.KingLevel,
+        Deck:          append([]DeckCard(nil), profile.Deck...),
     }
+    player.dealHand()
     
     return player
 }
@@ -117,7 +147,7 @@ func (g *Game) PlaceTroopAtCell(col, row int, health, damage int, speed, attackR
     mob.Team = team
 
     // Set initial target based on position
-    if col < GridColumns/2 {
+    if col < g.Grid.Columns/2 {
         // Troops on left side target left Building (index 1)
         mob.TargetIndex = 1
     } else {
@@ -125,10 +155,9 @@ func (g *Game) PlaceTroopAtCell(col, row int, health, damage int, speed, attackR
         mob.TargetIndex = 2
     }
 
-    g.Troops = append(g.Troops, mob)
+    SpawnTroop(mob, team, g)
 }
 
-var nextTroopID = 0
 // Update the NewTroop function in spawn.go to initialize PrevPosition
 func NewTroop(x, y float64, health, damage int, speedInCells, attackRangeInCells, aggroDistanceInCells float64, clr color.RGBA, grid *GridSystem, sizeInCells float64) Troop {
     // Calculate actual pixel size from grid cells
@@ -142,7 +171,6 @@ func NewTroop(x, y float64, health, damage int, speedInCells, attackRangeInCells
     attackDelay := 20
 
     pos := Position{X: x, Y: y}
-    nextTroopID++
     return Troop{
         Position:      pos,
         PrevPosition:  pos,     // Initialize previous position to current position
@@ -159,12 +187,19 @@ func NewTroop(x, y float64, health, damage int, speedInCells, attackRangeInCells
         AttackDelay:   attackDelay,
         IsNearTarget:  false,              // Initialize as not near target
         LastTargetChange: 0,               // Initialize last target change time
-		ID: nextTroopID,
     }
 }
 
-// SpawnMob adds a new mob to the game
+// SpawnTroop adds a new troop to the game and assigns it a per-game ID
 func SpawnTroop(mob Troop, team int, g *Game) {
     mob.Team = team  // Set the team explicitly
+    if mob.TargetIndex == 0 {
+        mob.TargetIndex = laneForPosition(g, mob.Position)
+    }
+    g.NextTroopID++
+    mob.ID = g.NextTroopID
+    // A new troop is drawn where it lands, not sliding in from a template's
+    // history
+    mob.PositionHistory.Reset(mob.Position)
     g.Troops = append(g.Troops, mob)
 }
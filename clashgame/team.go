@@ -0,0 +1,29 @@
+// team.go
+package clashgame
+
+// NumTeams is how many sides a match has. Team 0 defends the top of the
+// arena and team 1 the bottom.
+const NumTeams = 2
+
+// PlayerTeam returns the team of the player at an index. Players alternate
+// teams, so in every match player 0 and player 1 are opponents, and player
+// t is the first player of team t.
+func PlayerTeam(player int) int {
+	return player % NumTeams
+}
+
+// EnemyTeam returns the team a team plays against
+func EnemyTeam(team int) int {
+	return 1 - team
+}
+
+// TeamPlayers lists the indexes of a team's players
+func (g *Game) TeamPlayers(team int) []int {
+	var players []int
+	for i := range g.Players {
+		if g.Players[i].Team == team {
+			players = append(players, i)
+		}
+	}
+	return players
+}
@@ -210,6 +210,18 @@ func (b *Building) Draw(screen *ebiten.Image, grid *GridSystem) {
         healthBarHeight,
         healthColor,
     )
+    
+    // Buildings being set up get the same pale overlay as deploying troops
+    if b.IsDeploying() {
+        ebitenutil.DrawRect(
+            screen,
+            b.Position.X - width/2,
+            b.Position.Y - height/2,
+            width,
+            height,
+            color.RGBA{255, 255, 255, 120},
+        )
+    }
 }
 
 // Helper function to draw building details
@@ -445,6 +457,10 @@ func (g *Game) Update() error {
     if !g.IsActive() {
         return nil
     }
+    
+    // Input handlers spawn troops directly, so hold the lock while they run
+    g.mu.Lock()
+    defer g.mu.Unlock()
 
     // Toggle grid visibility with G key
     if inpututil.IsKeyJustPressed(ebiten.KeyG) {
@@ -456,8 +472,18 @@ func (g *Game) Update() error {
         g.ShowTroopInfo = !g.ShowTroopInfo
     }
     
-    // Update troop selection system if initialized
+    // The map editor (E) takes over the mouse while it is open
+    if g.Editor != nil {
+        g.Editor.Update(g)
+        if g.Editor.Active {
+            return nil
+        }
+    }
+    
+    // Update troop selection system if initialized. A local player who
+    // plays from a hand only sees their hand in the bar.
     if g.TroopSelection != nil {
+        g.TroopSelection.SetHand(g.localHand())
         g.TroopSelection.Update()
     }
     
@@ -491,21 +517,10 @@ func (g *Game) Update() error {
                 }
             } else {
                 
-                if g.TroopSelection != nil && g.TroopSelection.SelectedTroop != "" {
-                    // Use selected troop from the troop selection system
+                // Deploys go through the command queue and are applied on
+                // the next tick; the local player is player 0
+                if g.TroopSelection != nil {
                     g.TroopSelection.DeploySelectedTroop(g, col, row, 0)
-                } else {
-                    // Default behavior - spawn a single regular troop
-                    g.PlaceTroopAtCell(
-                        col, row,
-                        100,        // Health
-                        40,         // Damage
-                        0.2,        // Speed (in grid cells per tick)
-                        1.5,        // Range (in grid cells)
-                        5.0,        // Aggro distance (in grid cells)
-                        g.Players[0].Color, // Same color as player
-                        0,          // Team 0 (friendly)
-                    )
                 }
                 
                 // Also check for troop selection (for detailed info)
@@ -546,21 +561,10 @@ func (g *Game) Update() error {
             } else {
                 // Click is in the game area - spawn troop
                 
-                if g.TroopSelection != nil && g.TroopSelection.SelectedTroop != "" {
-                    // Use selected troop from the troop selection system
+                // Deploys go through the command queue and are applied on
+                // the next tick, as player 1 of the enemy team
+                if g.TroopSelection != nil {
                     g.TroopSelection.DeploySelectedTroop(g, col, row, 1)
-                } else {
-                    // Default behavior - spawn a single regular enemy troop
-                    g.PlaceTroopAtCell(
-                        col, row,
-                        100,        // Health
-                        40,         // Damage
-                        0.2,        // Speed (in grid cells per tick)
-                        1.5,        // Range (in grid cells)
-                        5.0,        // Aggro distance (in grid cells)
-                        g.Players[1].Color, // Same color as enemy player
-                        1,          // Team 1 (enemy)
-                    )
                 }
             }
         }
@@ -572,7 +576,7 @@ func (g *Game) Update() error {
 }
 
 // Draw draws the projectile on the screen
-func (p *Projectile) Draw(screen *ebiten.Image, game Game) {
+func (p *Projectile) Draw(screen *ebiten.Image, game *Game) {
 	if p.Active {
 		// Draw the projectile as a circle
 		ebitenutil.DrawCircle(
@@ -618,6 +622,9 @@ func (p *Projectile) Draw(screen *ebiten.Image, game Game) {
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    
     // Draw background
     screen.Fill(color.RGBA{200, 200, 200, 255})
     
@@ -625,25 +632,50 @@ func (g *Game) Draw(screen *ebiten.Image) {
     g.Grid.Draw(screen)
     
     // IMPORTANT: Draw buildings
-    for _, player := range g.Players {
+    for team := range g.Teams {
+        towers := &g.Teams[team]
+        
         // Draw king building
-        player.KingBuilding.Draw(screen, g.Grid)
+        towers.KingBuilding.Draw(screen, g.Grid)
         
         // Draw regular buildings
-        for i := range player.Buildings {
-            player.Buildings[i].Draw(screen, g.Grid)
+        for i := range towers.Buildings {
+            towers.Buildings[i].Draw(screen, g.Grid)
+        }
+        
+        // Draw buildings placed from cards
+        for _, building := range g.DeployedBuildings {
+            if building.Active && building.Team == team {
+                building.Draw(screen, g.Grid)
+            }
+        }
+    }
+    
+    // Show each player's latest emote above their king tower for two
+    // seconds; teammates' emotes stack upwards
+    for i, player := range g.Players {
+        if player.LastEmote != "" && g.GameTime-player.LastEmoteTick < 50 {
+            king := g.Teams[player.Team].KingBuilding.Position
+            y := int(king.Y) - 40 - 16*(i/NumTeams)
+            ebitenutil.DebugPrintAt(screen, player.LastEmote, int(king.X)-20, y)
         }
     }
     
     // Draw projectiles
     for _, projectile := range g.Projectiles {
-        projectile.Draw(screen, *g)
+        projectile.Draw(screen, g)
     }
     
+    // Troops are drawn between their last two sim positions, so movement
+    // stays smooth at any frame rate. Each loop works on a copy of the troop,
+    // so moving it doesn't touch the simulation.
+    alpha := g.renderAlpha()
+    
     // Draw troops using enhanced visuals if available
     if g.TroopDrawer != nil {
         for i, troop := range g.Troops {
             if troop.Active {
+                troop.Position = troop.RenderPosition(alpha)
                 g.TroopDrawer.DrawTroop(screen, &troop)
                 
                 // Draw selection highlight if this troop is selected
@@ -661,21 +693,84 @@ func (g *Game) Draw(screen *ebiten.Image) {
     } else {
         // Fallback to original troop drawing
         for _, troop := range g.Troops {
+            troop.Position = troop.RenderPosition(alpha)
             troop.Draw(screen)
         }
     }
     
+    // Deploying troops get a pale overlay until they land
+    for _, troop := range g.Troops {
+        if troop.Active && troop.IsDeploying() {
+            troop.Position = troop.RenderPosition(alpha)
+            ebitenutil.DrawCircle(
+                screen,
+                troop.Position.X,
+                troop.Position.Y,
+                troop.Size/2,
+                color.RGBA{255, 255, 255, 120},
+            )
+        }
+    }
+    
+    // Draw landing effects as fading rings
+    for _, effect := range g.Effects {
+        progress := float64(g.GameTime-effect.StartTick) / float64(effect.Duration)
+        alpha := uint8(150 * (1 - progress))
+        ebitenutil.DrawCircle(
+            screen,
+            effect.Position.X,
+            effect.Position.Y,
+            effect.Radius*(0.5+progress/2),
+            color.RGBA{255, 255, 200, alpha},
+        )
+    }
+    
+    // Show the mode, the time left in the current phase and the elixir rate
+    g.drawMatchClock(screen)
+    
     // Draw troop selection UI if available
     if g.TroopSelection != nil {
         g.TroopSelection.Draw(screen)
     }
     
+    // Draw the map editor overlay on top of everything
+    if g.Editor != nil {
+        g.Editor.Draw(screen, g)
+    }
+    
     // Draw rest of UI (selected troop info, etc.)
     if g.ShowTroopInfo && g.SelectedTroopID >= 0 && g.SelectedTroopID < len(g.Troops) {
         // (existing code for troop info display)
     }
 }
 
+// drawMatchClock prints the match status in the top left corner
+func (g *Game) drawMatchClock(screen *ebiten.Image) {
+    mode := g.Config.Mode
+    var status string
+    switch g.Phase {
+    case PhaseEnded:
+        status = "match over"
+        if g.Winner != NoWinner {
+            status = fmt.Sprintf("team %d wins", g.Winner)
+        }
+    case PhaseOvertime:
+        status = "overtime " + formatClock(mode.LengthTicks()-g.GameTime)
+    default:
+        status = formatClock(mode.RegulationTicks() - g.GameTime)
+    }
+    if multiplier := mode.ElixirMultiplier(g.GameTime); multiplier != 1 && g.Phase != PhaseEnded {
+        status += fmt.Sprintf("  x%g elixir", multiplier)
+    }
+    ebitenutil.DebugPrintAt(screen, mode.Name+"  "+status, 10, 10)
+}
+
+// formatClock shows a number of ticks as minutes and seconds
+func formatClock(ticks int) string {
+    seconds := int(math.Ceil(TicksToSeconds(max(ticks, 0))))
+    return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
 // DebugCombatSystem prints information about troops and projectiles
 func DebugCombatSystem(game *Game) {
     // Only run debug every 60 ticks to avoid spamming console
@@ -683,8 +778,8 @@ func DebugCombatSystem(game *Game) {
         return
     }
     
-    fmt.Println("\n----- COMBAT SYSTEM DEBUG -----")
-    fmt.Printf("Game Time: %d\n", game.GameTime)
+    logln("\n----- COMBAT SYSTEM DEBUG -----")
+    logf("Game Time: %d\n", game.GameTime)
     
     // Count active troops by type and team
     meleeTroops := [2]int{0, 0} // [team0, team1]
@@ -692,7 +787,7 @@ func DebugCombatSystem(game *Game) {
     flyingTroops := [2]int{0, 0}
     
     // Print info for up to 5 troops
-    fmt.Println("Active Troops (sample):")
+    logln("Active Troops (sample):")
     sampleCount := 0
     
     for _, troop := range game.Troops {
@@ -701,7 +796,7 @@ func DebugCombatSystem(game *Game) {
         }
         
         template := GetTroopTemplate(&troop)
-        isMelee := troop.Range <= 1.0
+        isMelee := IsMeleeRange(troop.Range)
         isFlying := IsFlyingTroop(&troop)
         
         // Update counters
@@ -728,7 +823,7 @@ func DebugCombatSystem(game *Game) {
                 troopType = "Flying"
             }
             
-            fmt.Printf("  ID=%d, Name=%s, Team=%d, Type=%s, Range=%.1f, Health=%d/%d, HasProjectile=%s\n",
+            logf("  ID=%d, Name=%s, Team=%d, Type=%s, Range=%.1f, Health=%d/%d, HasProjectile=%s\n",
                       troop.ID, troop.Name, troop.Team, troopType, troop.Range, 
                       troop.Health, troop.MaxHealth, hasProjectile)
             
@@ -737,9 +832,9 @@ func DebugCombatSystem(game *Game) {
     }
     
     // Print troop counts
-    fmt.Printf("Team 0 Troops: %d Melee, %d Ranged, %d Flying\n", 
+    logf("Team 0 Troops: %d Melee, %d Ranged, %d Flying\n", 
                meleeTroops[0], rangedTroops[0], flyingTroops[0])
-    fmt.Printf("Team 1 Troops: %d Melee, %d Ranged, %d Flying\n", 
+    logf("Team 1 Troops: %d Melee, %d Ranged, %d Flying\n", 
                meleeTroops[1], rangedTroops[1], flyingTroops[1])
     
     // Print projectile info
@@ -750,11 +845,11 @@ func DebugCombatSystem(game *Game) {
         }
     }
     
-    fmt.Printf("Active Projectiles: %d\n", activeProjectiles)
+    logf("Active Projectiles: %d\n", activeProjectiles)
     
     // Print sample projectiles
     if len(game.Projectiles) > 0 {
-        fmt.Println("Projectile Samples:")
+        logln("Projectile Samples:")
         
         sampleCount = 0
         for i, p := range game.Projectiles {
@@ -762,30 +857,31 @@ func DebugCombatSystem(game *Game) {
                 continue
             }
             
-            fmt.Printf("  ID=%d, Name=%s, Team=%d, Damage=%d, Speed=%.2f, Size=%.1f, Pos=(%.1f,%.1f)\n",
+            logf("  ID=%d, Name=%s, Team=%d, Damage=%d, Speed=%.2f, Size=%.1f, Pos=(%.1f,%.1f)\n",
                       i, p.Name, p.Team, p.Damage, p.Speed, p.Size, p.Position.X, p.Position.Y)
             
             sampleCount++
         }
     }
     
-    fmt.Println("-------------------------------")
+    logln("-------------------------------")
 }
 
 // Add this helper function to check template integrity
-func CheckTroopTemplateIntegrity() {
-    fmt.Println("\n----- CHECKING TROOP TEMPLATES -----")
+func CheckTroopTemplateIntegrity(catalog *Catalog) {
+    logln("\n----- CHECKING TROOP TEMPLATES -----")
     
     // Count how many templates we have
-    fmt.Printf("Total troop templates: %d\n", len(TroopTemplateMap))
+    logf("Total troop templates: %d\n", catalog.NumTroops())
     
     meleeTroops := 0
     rangedTroops := 0
     flyingTroops := 0
     
     // Check each template
-    for name, template := range TroopTemplateMap {
-        isMelee := template.Range <= 1.0
+    for _, name := range catalog.TroopNames() {
+        template, _ := catalog.Troop(name)
+        isMelee := IsMeleeRange(template.Range)
         isFlying := template.FlyingHeight > 0
         
         // Categorize the troop
@@ -801,24 +897,24 @@ func CheckTroopTemplateIntegrity() {
         hasProjectile := template.Projectile.Name != "" && template.Projectile.Name != "none"
         
         if isMelee && hasProjectile {
-            fmt.Printf("WARNING: Melee troop '%s' has projectile '%s' assigned\n", 
+            logf("WARNING: Melee troop '%s' has projectile '%s' assigned\n", 
                        name, template.Projectile.Name)
         } else if !isMelee && !hasProjectile {
-            fmt.Printf("WARNING: Ranged troop '%s' has no projectile assigned\n", name)
+            logf("WARNING: Ranged troop '%s' has no projectile assigned\n", name)
         }
     }
     
-    fmt.Printf("Template counts: %d Melee, %d Ranged, %d Flying\n", 
+    logf("Template counts: %d Melee, %d Ranged, %d Flying\n", 
                meleeTroops, rangedTroops, flyingTroops)
                
-    fmt.Println("----------------------------------")
+    logln("----------------------------------")
 }
 
 // Call this from your game.Update() method to enable debugging
 func (g *Game) EnableCombatDebugging() {
     // Check if D key is pressed
     if inpututil.IsKeyJustPressed(ebiten.KeyD) {
-        CheckTroopTemplateIntegrity()
+        CheckTroopTemplateIntegrity(g.Catalog)
     }
     
     // Run combat debug every 60 frames
@@ -2,96 +2,111 @@
 package clashgame
 
 import (
-	"encoding/csv"
+	"math"
+	"errors"
 	"fmt"
 	"image/color"
-	"io"
-	"os"
-	"strconv"
-	"strings"
+	"io/fs"
 )
 
 // TroopTemplate contains the extended properties from the CSV file
 // Only including the most relevant fields from the 336 columns
 type TroopTemplate struct {
 	// Basic identifiers
-	Name            string
-	Rarity          string
-	Tribe           string
-	
-	// Core stats from CSV
-	SightRange      float64
-	DeployTime      float64
-	ChargeRange     float64
-	Speed           float64
-	Hitpoints       int
-	HitSpeed        float64
-	LoadTime        float64
-	Damage          int
-	DamageSpecial   int
-	Range           float64
-	MinimumRange    float64
+	Name            string  `csv:"Name"`
+	Rarity          string  `csv:"Rarity"`
+	Tribe           string  `csv:"Tribe"`
+	
+	// Core stats from CSV, converted to cells, seconds and cells per tick
+	// (see units.go)
+	SightRange      float64 `csv:"SightRange" unit:"distance"`
+	DeployTime      float64 `csv:"DeployTime" unit:"ms"`
+	ChargeRange     float64 `csv:"ChargeRange" unit:"distance"`
+	Speed           float64 `csv:"Speed" unit:"speed"`
+	Hitpoints       int     `csv:"Hitpoints"`
+	HitSpeed        float64 `csv:"HitSpeed" unit:"ms"`
+	LoadTime        float64 `csv:"LoadTime" unit:"ms"`
+	Damage          int     `csv:"Damage"`
+	DamageSpecial   int     `csv:"DamageSpecial"`
+	Range           float64 `csv:"Range" unit:"distance"`
+	MinimumRange    float64 `csv:"MinimumRange" unit:"distance"`
 	
 	// Attack properties
-	AttacksGround   bool
-	AttacksAir      bool
-	AreaDamageRadius float64
-	TargetOnlyBuildings bool
-	TargetOnlyTroops bool
+	AttacksGround   bool    `csv:"AttacksGround"`
+	AttacksAir      bool    `csv:"AttacksAir"`
+	AreaDamageRadius float64 `csv:"AreaDamageRadius" unit:"distance"`
+	TargetOnlyBuildings bool `csv:"TargetOnlyBuildings"`
+	TargetOnlyTroops bool   `csv:"TargetOnlyTroops"`
+	TargetOnlyKingBuilding bool `csv:"TargetOnlyKingbuilding"` // Ignores everything but the enemy king tower
+	SpecialAttacksToIgnoreList bool `csv:"SpecialAttacksToIgnoreList"` // Targets hit by a special attack are never retargeted
 	
 	// Special abilities
-	DeathDamage     int
-	DeathDamageRadius float64
-	LifeTime        float64
-	SpawnInterval   float64
-	SpawnNumber     int
+	DeathDamage     int     `csv:"DeathDamage"`
+	DeathDamageRadius float64 `csv:"DeathDamageRadius" unit:"distance"`
+	DeathEffect     string  `csv:"DeathEffect"`                // Effect played when the troop dies
+	DeathPushBack   float64 `csv:"DeathPushBack" unit:"distance"` // Knockback dealt by the death damage, in grid cells
+	DeathSpawnCharacter string `csv:"DeathSpawnCharacter"`     // Troop left behind on death, e.g. Golemites
+	DeathSpawnCount int     `csv:"DeathSpawnCount"`
+	LifeTime        float64 `csv:"LifeTime" unit:"ms"`      // Seconds before the troop expires (0 = forever)
+	Kamikaze        bool    `csv:"Kamikaze"`                   // Dies on its first attack, like the spirits
+	SpawnInterval   float64 `csv:"SpawnInterval" unit:"ms"`
+	SpawnNumber     int     `csv:"SpawnNumber"`
+	SpawnRadius     float64 `csv:"SpawnRadius" unit:"distance"`   // Spread of units summoned together, in grid cells
+	SpawnEffect     string  `csv:"SpawnEffect"`                // Effect played when the troop lands
+	SpawnPushback   float64 `csv:"SpawnPushback" unit:"distance"` // Knockback dealt to nearby enemies on landing, in grid cells
+	SpawnPushbackRadius float64 `csv:"SpawnPushbackRadius" unit:"distance"`
 	
 	// Visual properties
-	Scale           float64
-	CollisionRadius float64
-	FlyingHeight    float64
+	Scale           float64 `csv:"Scale" unit:"percent"`
+	CollisionRadius float64 `csv:"CollisionRadius" unit:"distance"` // Radius of the body in grid cells
+	Mass            float64 `csv:"Mass"`                         // Decides who gets pushed in collisions
+	IgnorePushback  bool    `csv:"IgnorePushback"`               // Immune to knockback
+	FlyingHeight    float64 `csv:"FlyingHeight" unit:"distance"`
 	
 	// Additional properties can be added as needed
 	Projectile		ProjectileTemplate
 }
 
+// troopCSVRow holds the troop columns that don't map straight onto a
+// TroopTemplate field
+type troopCSVRow struct {
+	Projectile          string `csv:"Projectile"`          // Linked to a ProjectileTemplate after loading
+	TargetOnlybuildings bool   `csv:"TargetOnlybuildings"` // Second spelling of TargetOnlyBuildings
+}
+
 // ExtendedTroop wraps the base Troop struct with extended properties
 type ExtendedTroop struct {
 	Troop          // Embed the base Troop struct
 	Template    *TroopTemplate
 }
 
-// TroopTemplateMap is a map of troop names to their templates
-var TroopTemplateMap map[string]*TroopTemplate
-
-// LoadTroopTemplates loads troop templates from a CSV file
-func LoadTroopTemplates(filepath string) error {
-	// Initialize the map
-	TroopTemplateMap = make(map[string]*TroopTemplate)
-	
+// LoadTroopTemplates loads troop templates from a CSV file in fsys, linking
+// each one to its projectile from the given projectile templates. If the CSV
+// can't be used it falls back to the built-in default troops.
+func LoadTroopTemplates(fsys fs.FS, path string, projectiles map[string]*ProjectileTemplate) (map[string]*TroopTemplate, error) {
 	// Try to load from CSV
-	err := loadTroopTemplatesFromCSV(filepath)
+	troops, err := loadTroopTemplatesFromCSV(fsys, path, projectiles)
 	if err != nil {
-		fmt.Printf("Warning: Failed to load troop templates from CSV: %v\n", err)
-		fmt.Println("Falling back to default troop templates")
-		InitializeWithDefaultTroops()
-		return nil
+		logf("Warning: Failed to load troop templates from CSV: %v\n", err)
+		logln("Falling back to default troop templates")
+		return defaultTroopTemplates(), nil
 	}
 	
 	// If the CSV was loaded but no valid troops were found, use defaults
-	if len(TroopTemplateMap) == 0 {
-		fmt.Println("Warning: No valid troop templates found in CSV")
-		fmt.Println("Falling back to default troop templates")
-		InitializeWithDefaultTroops()
+	if len(troops) == 0 {
+		logln("Warning: No valid troop templates found in CSV")
+		logln("Falling back to default troop templates")
+		return defaultTroopTemplates(), nil
 	}
 	
-	return nil
+	return troops, nil
 }
 
-// NewExtendedTroop creates a new ExtendedTroop with a template
-func NewExtendedTroop(x, y float64, troopName string, team int, grid *GridSystem) (*ExtendedTroop, error) {
+// NewExtendedTroop creates a new ExtendedTroop with a template from the
+// catalog, scaling hitpoints and damage to the card level
+func NewExtendedTroop(catalog *Catalog, x, y float64, troopName string, team, level int, grid *GridSystem) (*ExtendedTroop, error) {
 	// Get the template
-	template, exists := TroopTemplateMap[troopName]
+	template, exists := catalog.Troop(troopName)
 	if !exists {
 		return nil, fmt.Errorf("troop template not found: %s", troopName)
 	}
@@ -99,13 +114,8 @@ func NewExtendedTroop(x, y float64, troopName string, team int, grid *GridSystem
 	// Calculate appropriate size based on collision radius or scale
 	sizeInCells := 0.8 // Default size
 	if template.CollisionRadius > 0 {
-		// Use collision radius if available
-		sizeInCells = template.CollisionRadius / 500
-		if sizeInCells < 0.5 {
-			sizeInCells = 1 // Minimum size
-		} else if sizeInCells > 2 {
-			sizeInCells = 3 // Maximum size
-		}
+		// The troop's body is its collision circle
+		sizeInCells = math.Max(0.6, math.Min(3, template.CollisionRadius*2))
 	} else if template.Scale > 0 {
 		// Otherwise use scale
 		sizeInCells = 0.8 * template.Scale
@@ -114,12 +124,16 @@ func NewExtendedTroop(x, y float64, troopName string, team int, grid *GridSystem
 	// Get color based on team and rarity
 	troopColor := GetTroopColorByTeam(team, template.Rarity)
 	
+	// Apply the card level to the CSV stats
+	level = ClampCardLevel(template.Rarity, level, MaxCardLevel)
+	multiplier := LevelMultiplier(template.Rarity, level)
+	
 	// Create the base troop using the template values
 	troop := NewTroop(
 		x,
 		y,
-		template.Hitpoints,
-		template.Damage,
+		ScaleStat(template.Hitpoints, multiplier),
+		ScaleStat(template.Damage, multiplier),
 		template.Speed,
 		template.Range,
 		template.SightRange, // Using SightRange as AggroDistance
@@ -130,15 +144,22 @@ func NewExtendedTroop(x, y float64, troopName string, team int, grid *GridSystem
 
 	InitTroopMovement(&troop)
 	
-	// Set name from template
+	// Set name and template
 	troop.Name = template.Name
+	troop.Template = template
+	troop.Level = level
+	troop.Mass = template.Mass
+	
+	// Troops spend their deploy time on the field before they can act
+	troop.DeployTicks = SecondsToTicks(template.DeployTime)
+	troop.LifeTicks = SecondsToTicks(template.LifeTime)
 	
 	// Set team
 	troop.Team = team
 	
 	// Set attack delay based on hit speed
 	if template.HitSpeed > 0 {
-		troop.AttackDelay = int(60 / template.HitSpeed) // Assuming 60 ticks per second
+		troop.AttackDelay = SecondsToTicks(template.HitSpeed)
 	}
 	
 	// Create the extended troop
@@ -157,128 +178,128 @@ func (et *ExtendedTroop) GetAttackDelay() int {
 	if et.Template.HitSpeed <= 0 {
 		return 20 // Default attack delay
 	}
-	// Convert hit speed to game ticks (assuming 60 ticks per second)
-	return int(60 / et.Template.HitSpeed)
+	return SecondsToTicks(et.Template.HitSpeed)
 }
 
 // SpawnExtendedTroop adds an extended troop to the game
 func SpawnExtendedTroop(troopName string, x, y float64, team int, g *Game) error {
-	extendedTroop, err := NewExtendedTroop(x, y, troopName, team, g.Grid)
+	// Debug spawns use the card levels of the team's first player
+	level := g.Players[team].CardLevel(troopName)
+	
+	extendedTroop, err := NewExtendedTroop(g.Catalog, x, y, troopName, team, level, g.Grid)
 	if err != nil {
 		return err
 	}
 	
 	// Add to game's troop list
-	g.Troops = append(g.Troops, extendedTroop.Troop)
+	SpawnTroop(extendedTroop.Troop, team, g)
 	
 	return nil
 }
 
-// InitializeTroopSystem sets up the troop system by loading templates
-func InitializeTroopSystem(csvPath string) error {
-	return LoadTroopTemplates(csvPath)
-}
-
-// Default troops when CSV loading fails
+// Default troops when CSV loading fails, in the units the CSV loader
+// converts to
 var defaultTroops = map[string]*TroopTemplate{
 	"Knight": {
 		Name:           "Knight",
 		Rarity:         "Common",
 		Tribe:          "Ground",
-		SightRange:     5.0,
+		SightRange:     TilesToCells(5.5),
 		DeployTime:     1.0,
-		Speed:          0.15,
+		Speed:          SpeedToCellsPerTick(60),
 		Hitpoints:      150,
 		HitSpeed:       1.2,
 		Damage:         75,
-		Range:          0.8,
+		Range:          TilesToCells(1.2),
 		AttacksGround:  true,
 		AttacksAir:     false,
 		Scale:          1.0,
-		CollisionRadius: 0.7,
+		CollisionRadius: TilesToCells(0.5),
+		Mass:           6,
 	},
 	"Archer": {
 		Name:           "Archer",
 		Rarity:         "Common",
 		Tribe:          "Ground",
-		SightRange:     5.5,
+		SightRange:     TilesToCells(5.5),
 		DeployTime:     0.5,
-		Speed:          0.2,
+		Speed:          SpeedToCellsPerTick(60),
 		Hitpoints:      80,
 		HitSpeed:       0.7,
 		Damage:         40,
-		Range:          4.0,
+		Range:          TilesToCells(5),
 		AttacksGround:  true,
 		AttacksAir:     true,
 		Scale:          0.9,
-		CollisionRadius: 0.6,
+		CollisionRadius: TilesToCells(0.5),
+		Mass:           3,
 	},
 	"Skeleton": {
 		Name:           "Skeleton",
 		Rarity:         "Common",
 		Tribe:          "Ground",
-		SightRange:     4.0,
+		SightRange:     TilesToCells(5.5),
 		DeployTime:     0.0,
-		Speed:          0.25,
+		Speed:          SpeedToCellsPerTick(90),
 		Hitpoints:      40,
 		HitSpeed:       0.5,
 		Damage:         25,
-		Range:          0.5,
+		Range:          TilesToCells(0.5),
 		AttacksGround:  true,
 		AttacksAir:     false,
 		Scale:          0.7,
-		CollisionRadius: 0.4,
+		CollisionRadius: TilesToCells(0.3),
+		Mass:           1,
 	},
 	"Giant": {
 		Name:           "Giant",
 		Rarity:         "Rare",
 		Tribe:          "Ground",
-		SightRange:     5.0,
+		SightRange:     TilesToCells(7.5),
 		DeployTime:     1.0,
-		Speed:          0.1,
+		Speed:          SpeedToCellsPerTick(45),
 		Hitpoints:      800,
 		HitSpeed:       1.5,
 		Damage:         100,
-		Range:          0.8,
+		Range:          TilesToCells(1.2),
 		AttacksGround:  true,
 		AttacksAir:     false,
 		TargetOnlyBuildings: true,
 		Scale:          1.5,
-		CollisionRadius: 1.0,
+		CollisionRadius: TilesToCells(0.75),
+		Mass:           18,
+		IgnorePushback: true,
 	},
 	"BabyDragon": {
 		Name:           "BabyDragon",
 		Rarity:         "Epic",
 		Tribe:          "Air",
-		SightRange:     6.0,
+		SightRange:     TilesToCells(5.5),
 		DeployTime:     1.0,
-		Speed:          0.2,
+		Speed:          SpeedToCellsPerTick(90),
 		Hitpoints:      200,
 		HitSpeed:       1.3,
 		Damage:         60,
-		Range:          3.0,
+		Range:          TilesToCells(3.5),
 		AttacksGround:  true,
 		AttacksAir:     true,
-		AreaDamageRadius: 1.5,
+		AreaDamageRadius: TilesToCells(1.5),
 		Scale:          1.2,
-		CollisionRadius: 0.8,
-		FlyingHeight:   1.0,
+		CollisionRadius: TilesToCells(0.5),
+		Mass:           5,
+		FlyingHeight:   TilesToCells(3.5),
 	},
 }
 
-// InitializeWithDefaultTroops sets up default troop templates when CSV loading fails
-func InitializeWithDefaultTroops() {
-	// Initialize the map if it doesn't exist
-	if TroopTemplateMap == nil {
-		TroopTemplateMap = make(map[string]*TroopTemplate)
-	}
-	
-	// Add default troops
+// defaultTroopTemplates returns copies of the default troops used when CSV
+// loading fails
+func defaultTroopTemplates() map[string]*TroopTemplate {
+	troops := make(map[string]*TroopTemplate, len(defaultTroops))
 	for name, template := range defaultTroops {
-		TroopTemplateMap[name] = template
+		copied := *template
+		troops[name] = &copied
 	}
-	
-	fmt.Println("Initialized with default troop templates")
+	return troops
 }
 
 // Convenience method to create team-specific colors for troops
@@ -331,8 +352,8 @@ func GetTroopColorByTeam(team int, rarity string) color.RGBA {
 }
 
 // Helper function to get troop display name
-func GetTroopDisplayName(templateName string) string {
-	template, exists := TroopTemplateMap[templateName]
+func GetTroopDisplayName(catalog *Catalog, templateName string) string {
+	template, exists := catalog.Troop(templateName)
 	if !exists {
 		return templateName
 	}
@@ -340,154 +361,41 @@ func GetTroopDisplayName(templateName string) string {
 	return template.Name
 }
 
-func loadTroopTemplatesFromCSV(filepath string) error {
-	// Open the CSV file
-	file, err := os.Open(filepath)
+func loadTroopTemplatesFromCSV(fsys fs.FS, path string, projectiles map[string]*ProjectileTemplate) (map[string]*TroopTemplate, error) {
+	table, err := ReadCSVTable(fsys, path)
 	if err != nil {
-		return fmt.Errorf("failed to open CSV file: %v", err)
-	}
-	defer file.Close()
-
-	// Parse the CSV file
-	reader := csv.NewReader(file)
-	
-	// Read the header
-	header, err := reader.Read()
-	if err != nil {
-		return fmt.Errorf("failed to read CSV header: %v", err)
-	}
-	
-	// Read the type information (second row, defines data types)
-	_, err = reader.Read()
-	if err != nil {
-		return fmt.Errorf("failed to read CSV types: %v", err)
-	}
-	
-	// Read the type descriptor row (third row)
-	_, err = reader.Read()
-	if err != nil {
-		return fmt.Errorf("failed to read CSV type descriptors: %v", err)
-	}
-	
-	// Create a map to store column indices for easier access
-	columnMap := make(map[string]int)
-	for i, col := range header {
-		columnMap[col] = i
+		return nil, err
 	}
 	
-	// Initialize the template map if it doesn't exist yet
-	if TroopTemplateMap == nil {
-		TroopTemplateMap = make(map[string]*TroopTemplate)
-	}
-	
-	// Read the remaining rows (actual troop data)
-	rowCount := 0
-	loadedCount := 0
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			fmt.Printf("Warning: failed to read CSV row %d: %v\n", rowCount+4, err)
-			continue
-		}
-		rowCount++
-		
+	troops := make(map[string]*TroopTemplate)
+	var errs []error
+	for i, record := range table.Rows {
 		// Skip empty rows or rows with "NOTINUSE" in the name
-		if len(record) == 0 || record[0] == "" || strings.Contains(record[0], "NOTINUSE") {
+		if skipCSVRow(record) {
 			continue
 		}
 		
-		// Extract troop data from the record
-		troopName := record[columnMap["Name"]]
-		if troopName == "" {
-			continue // Skip rows without a name
-		}
-
-		projectileName := getStringValue(record, columnMap, "Projectile")
-		
-		// Create a new template
-		template := &TroopTemplate{
-			Name:               troopName,
-			Rarity:             getStringValue(record, columnMap, "Rarity"),
-			Tribe:              getStringValue(record, columnMap, "Tribe"),
-			SightRange:         getFloatValue(record, columnMap, "SightRange") / 1000, 
-			DeployTime:         getFloatValue(record, columnMap, "DeployTime") / 1000, 
-			ChargeRange:        getFloatValue(record, columnMap, "ChargeRange") / 1000, 
-			Speed:              getFloatValue(record, columnMap, "Speed") / 400, 
-			Hitpoints:          getIntValue(record, columnMap, "Hitpoints"),
-			HitSpeed:           getFloatValue(record, columnMap, "HitSpeed") / 1000, 
-			LoadTime:           getFloatValue(record, columnMap, "LoadTime") / 1000, 
-			Damage:             getIntValue(record, columnMap, "Damage"),
-			DamageSpecial:      getIntValue(record, columnMap, "DamageSpecial"),
-			Range:              getFloatValue(record, columnMap, "Range") / 1500, 
-			MinimumRange:       getFloatValue(record, columnMap, "MinimumRange") / 1500, 
-			AttacksGround:      getBoolValue(record, columnMap, "AttacksGround"),
-			AttacksAir:         getBoolValue(record, columnMap, "AttacksAir"),
-			AreaDamageRadius:   getFloatValue(record, columnMap, "AreaDamageRadius") / 1000, 
-			TargetOnlyBuildings: getBoolValue(record, columnMap, "TargetOnlyBuildings"),
-			TargetOnlyTroops:   getBoolValue(record, columnMap, "TargetOnlyTroops"),
-			DeathDamage:        getIntValue(record, columnMap, "DeathDamage"),
-			DeathDamageRadius:  getFloatValue(record, columnMap, "DeathDamageRadius") / 1000, 
-			LifeTime:           getFloatValue(record, columnMap, "LifeTime") / 1000, 
-			SpawnInterval:      getFloatValue(record, columnMap, "SpawnInterval") / 1000, 
-			SpawnNumber:        getIntValue(record, columnMap, "SpawnNumber"),
-			Scale:              getFloatValue(record, columnMap, "Scale") / 100, 
-			CollisionRadius:    getFloatValue(record, columnMap, "CollisionRadius") / 100, 
-			FlyingHeight:       getFloatValue(record, columnMap, "FlyingHeight") / 100,
-			// Projectile field will be set below
+		template := &TroopTemplate{}
+		var extra troopCSVRow
+		if err := errors.Join(table.Unmarshal(i, template), table.Unmarshal(i, &extra)); err != nil {
+			errs = append(errs, err)
+			continue
 		}
 		
-		linkTroopToProjectile(template, projectileName)
-		
-		// Add the template to the map
-		TroopTemplateMap[troopName] = template
-		loadedCount++
+		// The CSV spells this flag two ways; either one makes a building targeter
+		template.TargetOnlyBuildings = template.TargetOnlyBuildings || extra.TargetOnlybuildings
+		linkTroopToProjectile(template, extra.Projectile, projectiles)
 		
+		troops[template.Name] = template
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 	
 	// Check if we loaded any templates
-	if loadedCount == 0 {
-		return fmt.Errorf("no valid troop templates found in CSV")
+	if len(troops) == 0 {
+		return nil, fmt.Errorf("no valid troop templates found in CSV")
 	}
 	
-	return nil
+	return troops, nil
 }
-
-// Helper functions to extract typed values from CSV records
-func getStringValue(record []string, columnMap map[string]int, column string) string {
-	if index, exists := columnMap[column]; exists && index < len(record) {
-		return record[index]
-	}
-	return ""
-}
-
-func getIntValue(record []string, columnMap map[string]int, column string) int {
-	if index, exists := columnMap[column]; exists && index < len(record) {
-		if record[index] != "" {
-			if val, err := strconv.Atoi(record[index]); err == nil {
-				return val
-			}
-		}
-	}
-	return 0
-}
-
-func getFloatValue(record []string, columnMap map[string]int, column string) float64 {
-	if index, exists := columnMap[column]; exists && index < len(record) {
-		if record[index] != "" {
-			if val, err := strconv.ParseFloat(record[index], 64); err == nil {
-				return val
-			}
-		}
-	}
-	return 0
-}
-
-func getBoolValue(record []string, columnMap map[string]int, column string) bool {
-	if index, exists := columnMap[column]; exists && index < len(record) {
-		return strings.ToLower(record[index]) == "true"
-	}
-	return false
-}
\ No newline at end of file
@@ -2,9 +2,21 @@ package clashgame
 
 import "math"
 
+// Lanes stored in Troop.TargetIndex. Each lane leads to the princess tower
+// at Buildings[lane-1].
+const (
+    LaneLeft  = 1
+    LaneRight = 2
+)
+
 // FindNearestEnemyTroop finds the closest enemy troop within the aggro radius
 // that isn't separated by water (on the same side of the river)
 func FindNearestEnemyTroop(game *Game, troop *Troop) *Troop {
+    // Building targeters never go after troops
+    if TargetsOnlyBuildings(troop) || TargetsOnlyKingBuilding(troop) {
+        return nil
+    }
+    
     // Convert aggro radius from tiles to pixels
     aggroRadius := troop.AggroDistance * game.Grid.CellWidth
     
@@ -12,9 +24,9 @@ func FindNearestEnemyTroop(game *Game, troop *Troop) *Troop {
     troopIsFlying := IsFlyingTroop(troop)
     
     // Determine which side of the river the troop is on
-    // We use the water rows (15 and 16) as dividing line
-    waterStartRow := 15
-    waterEndRow := 16
+    // We use the water rows as dividing line
+    waterStartRow := game.Grid.RiverStartRow
+    waterEndRow := game.Grid.RiverEndRow
     
     _, troopRow := game.Grid.PositionToCell(troop.Position)
     var troopSide int
@@ -34,10 +46,11 @@ func FindNearestEnemyTroop(game *Game, troop *Troop) *Troop {
     for i := range game.Troops {
         otherTroop := &game.Troops[i]
         
-        // Skip inactive, same team, or same troop
+        // Skip inactive, same team, same or ignored troop
         if !otherTroop.Active || 
            otherTroop.Team == troop.Team || 
-           otherTroop.ID == troop.ID {
+           otherTroop.ID == troop.ID ||
+           troop.IgnoredTargets[otherTroop.ID] {
             continue
         }
         
@@ -89,7 +102,9 @@ func FindNearestEnemyTroop(game *Game, troop *Troop) *Troop {
 }
 
 // FindNearestEnemyBuilding finds the closest enemy building within the aggro radius
-// that isn't separated by water (on the same side of the river)
+// that isn't separated by water (on the same side of the river). Deployed
+// buildings count too, which is what lets a building in the middle pull
+// building targeters out of their lane.
 func FindNearestEnemyBuilding(game *Game, troop *Troop) (*Building, bool) {
     // Convert aggro radius from tiles to pixels
     aggroRadius := troop.AggroDistance * game.Grid.CellWidth * 3
@@ -98,8 +113,8 @@ func FindNearestEnemyBuilding(game *Game, troop *Troop) (*Building, bool) {
     troopIsFlying := IsFlyingTroop(troop)
     
     // Determine which side of the river the troop is on
-    waterStartRow := 15
-    waterEndRow := 16
+    waterStartRow := game.Grid.RiverStartRow
+    waterEndRow := game.Grid.RiverEndRow
     
     _, troopRow := game.Grid.PositionToCell(troop.Position)
     var troopSide int
@@ -117,19 +132,18 @@ func FindNearestEnemyBuilding(game *Game, troop *Troop) (*Building, bool) {
     closestDistance := aggroRadius + 1 // Start outside aggro radius
     
     // Get enemy team
-    enemyTeam := 1 - troop.Team
+    enemyTeam := EnemyTeam(troop.Team)
     
     // Skip if troop only targets troops
     if TargetsOnlyTroops(troop) {
         return nil, false
     }
     
-    // Check regular buildings first
-    for i := range game.Players[enemyTeam].Buildings {
-        building := &game.Players[enemyTeam].Buildings[i]
+    // Check princess towers and deployed buildings first
+    for _, building := range teamBuildings(game, enemyTeam) {
         
-        // Skip inactive buildings
-        if !building.Active {
+        // Skip inactive buildings, and all of them for king-only troops
+        if !building.Active || TargetsOnlyKingBuilding(troop) {
             continue
         }
         
@@ -175,7 +189,7 @@ func FindNearestEnemyBuilding(game *Game, troop *Troop) (*Building, bool) {
     }
     
     // Check king building
-    kingBuilding := &game.Players[enemyTeam].KingBuilding.Building
+    kingBuilding := &game.Teams[enemyTeam].KingBuilding.Building
     if kingBuilding.Active {
         // Calculate distance, taking into account building size
         buildingWidth, buildingHeight := kingBuilding.GetPixelDimensions(game.Grid)
@@ -221,4 +235,126 @@ func FindNearestEnemyBuilding(game *Game, troop *Troop) (*Building, bool) {
     }
     
     return closestBuilding, isKingBuilding
-}
\ No newline at end of file
+}
+
+// UpdateTroopTargets refreshes every troop's target once per tick. A troop
+// that is attacking stays locked on its target until the target dies, leaves
+// its sight range or can no longer be attacked. Troops that are still walking
+// toward a building re-evaluate, so they can be distracted by troops or
+// pulled by a closer building, like in Clash Royale.
+func UpdateTroopTargets(game *Game) {
+    for i := range game.Troops {
+        troop := &game.Troops[i]
+        if !troop.Active || troop.IsDeploying() {
+            continue
+        }
+        
+        if hasValidTroopTarget(game, troop) {
+            continue
+        }
+        troop.TargetTroopID = 0
+        
+        building := troop.TargetBuilding
+        if building != nil && !building.Active {
+            // The building is gone; head for whichever lane we ended up in
+            troop.TargetBuilding = nil
+            troop.TargetIndex = laneForPosition(game, troop.Position)
+        }
+        if troop.TargetBuilding != nil && troop.IsAttacking {
+            continue
+        }
+        
+        acquireTarget(game, troop)
+    }
+}
+
+// hasValidTroopTarget reports whether the troop's locked enemy troop can
+// still be chased
+func hasValidTroopTarget(game *Game, troop *Troop) bool {
+    if troop.TargetTroopID == 0 {
+        return false
+    }
+    
+    target := findTroopByID(game, troop.TargetTroopID)
+    if target == nil || troop.IgnoredTargets[target.ID] {
+        return false
+    }
+    
+    // Leaving sight range breaks the lock
+    sightRange := troop.AggroDistance*game.Grid.CellWidth + target.Size/2
+    return Distance(troop.Position, target.Position) <= sightRange
+}
+
+// acquireTarget picks the closest valid troop or building in sight. Troop
+// targets are compared by center distance and buildings by edge distance, so
+// a troop standing next to a tower still gets noticed.
+func acquireTarget(game *Game, troop *Troop) {
+    enemy := FindNearestEnemyTroop(game, troop)
+    building, _ := FindNearestEnemyBuilding(game, troop)
+    
+    if enemy != nil && building != nil {
+        width, height := building.GetPixelDimensions(game.Grid)
+        buildingDist := Distance(troop.Position, building.Position) - math.Max(width, height)/2
+        if Distance(troop.Position, enemy.Position) > buildingDist {
+            enemy = nil
+        }
+    }
+    
+    switch {
+    case enemy != nil:
+        if troop.TargetTroopID != enemy.ID {
+            troop.LastTargetChange = game.GameTime
+        }
+        troop.TargetTroopID = enemy.ID
+        troop.TargetBuilding = nil
+    case building != nil:
+        if troop.TargetBuilding != building {
+            troop.LastTargetChange = game.GameTime
+        }
+        troop.TargetBuilding = building
+    }
+}
+
+// laneForPosition returns the lane a position belongs to
+func laneForPosition(game *Game, pos Position) int {
+    col, _ := game.Grid.PositionToCell(pos)
+    if col < game.Grid.Columns/2 {
+        return LaneLeft
+    }
+    return LaneRight
+}
+
+// laneTarget returns the building a troop without a target marches toward:
+// the princess tower in its lane, or the king tower once that one has fallen
+func laneTarget(game *Game, troop *Troop) *Building {
+    enemy := &game.Teams[EnemyTeam(troop.Team)]
+    
+    if !TargetsOnlyKingBuilding(troop) {
+        lane := troop.TargetIndex
+        if lane != LaneLeft && lane != LaneRight {
+            lane = laneForPosition(game, troop.Position)
+        }
+        if lane-1 < len(enemy.Buildings) && enemy.Buildings[lane-1].Active {
+            return &enemy.Buildings[lane-1]
+        }
+    }
+    
+    return &enemy.KingBuilding.Building
+}
+
+// RecordSpecialAttack is called when a troop lands its special attack on a
+// target. Troops with SpecialAttacksToIgnoreList drop the target and never
+// pick it again.
+func RecordSpecialAttack(troop *Troop, target *Troop) {
+    template := GetTroopTemplate(troop)
+    if template == nil || !template.SpecialAttacksToIgnoreList {
+        return
+    }
+    
+    if troop.IgnoredTargets == nil {
+        troop.IgnoredTargets = make(map[int]bool)
+    }
+    troop.IgnoredTargets[target.ID] = true
+    troop.TargetTroopID = 0
+    troop.IsAttacking = false
+}
@@ -14,12 +14,19 @@ import (
 
 // TroopSelectionSystem manages troop selection and deployment
 type TroopSelectionSystem struct {
+	// Catalog the card bar lists troops from
+	Catalog *Catalog
+	
 	// Currently selected troop name
 	SelectedTroop string
 	
 	// Available troop names
 	TroopNames []string
 	
+	// The local player's hand is shown instead of the catalog, see SetHand
+	ShowingHand bool
+	NextCard    string // Card that cycles into the hand next
+	
 	// Category filters (Common, Rare, Epic, Legendary)
 	CurrentFilter string
 	
@@ -37,9 +44,10 @@ type TroopSelectionSystem struct {
 	MaxVisibleCards int
 }
 
-// NewTroopSelectionSystem creates a new selection system
-func NewTroopSelectionSystem() *TroopSelectionSystem {
+// NewTroopSelectionSystem creates a new selection system for a catalog
+func NewTroopSelectionSystem(catalog *Catalog) *TroopSelectionSystem {
 	system := &TroopSelectionSystem{
+		Catalog:         catalog,
 		SelectedTroop:   "",
 		TroopNames:      make([]string, 0),
 		CurrentFilter:   "All",
@@ -65,14 +73,14 @@ func NewTroopSelectionSystem() *TroopSelectionSystem {
 	return system
 }
 
-// ReloadTroopNames updates the troop names list from the template map
+// ReloadTroopNames updates the card list from the catalog's troop cards
 func (ts *TroopSelectionSystem) ReloadTroopNames() {
-	ts.TroopNames = make([]string, 0, len(TroopTemplateMap))
+	ts.TroopNames = make([]string, 0, ts.Catalog.NumTroops())
 	
-	// Add all troop names from template map
-	for name := range TroopTemplateMap {
-		// Skip NOTINUSE troops
-		if !strings.Contains(name, "NOTINUSE") {
+	// Add all troop cards from the catalog
+	for _, name := range ts.Catalog.CardNames() {
+		// Skip spells and NOTINUSE troops
+		if _, isTroop := ts.Catalog.CardTroop(name); isTroop && !strings.Contains(name, "NOTINUSE") {
 			ts.TroopNames = append(ts.TroopNames, name)
 		}
 	}
@@ -81,6 +89,62 @@ func (ts *TroopSelectionSystem) ReloadTroopNames() {
 	sort.Strings(ts.TroopNames)
 }
 
+// SetCatalog switches the card bar to a new catalog, keeping the filter,
+// and the selection if the card still exists
+func (ts *TroopSelectionSystem) SetCatalog(catalog *Catalog) {
+	selected := ts.SelectedTroop
+	ts.Catalog = catalog
+	if ts.ShowingHand {
+		return
+	}
+	if ts.CurrentFilter == "All" {
+		ts.ReloadTroopNames()
+	} else {
+		ts.FilterByRarity(ts.CurrentFilter)
+	}
+	
+	ts.SelectedTroop = ""
+	for _, name := range ts.TroopNames {
+		if name == selected {
+			ts.SelectedTroop = selected
+		}
+	}
+	if ts.SelectedTroop == "" && len(ts.TroopNames) > 0 {
+		ts.SelectedTroop = ts.TroopNames[0]
+	}
+	ts.ScrollIndex = min(ts.ScrollIndex, max(0, len(ts.TroopNames)-ts.MaxVisibleCards))
+}
+
+// SetHand shows a player's hand and next card in the bar instead of the
+// whole catalog. Players without a hand (a nil hand) get the catalog back.
+func (ts *TroopSelectionSystem) SetHand(hand []string, next string) {
+	if hand == nil {
+		if ts.ShowingHand {
+			ts.ShowingHand = false
+			ts.NextCard = ""
+			ts.SetCatalog(ts.Catalog)
+		}
+		return
+	}
+	
+	ts.ShowingHand = true
+	ts.NextCard = next
+	ts.TroopNames = append(ts.TroopNames[:0], hand...)
+	ts.ScrollIndex = 0
+	
+	// A played card leaves the hand; nothing is selected until the player
+	// picks again
+	inHand := false
+	for _, name := range hand {
+		if name == ts.SelectedTroop {
+			inHand = true
+		}
+	}
+	if !inHand {
+		ts.SelectedTroop = ""
+	}
+}
+
 // Update handles input and selection changes
 func (ts *TroopSelectionSystem) Update() {
 	// Handle scrolling through troops
@@ -91,22 +155,24 @@ func (ts *TroopSelectionSystem) Update() {
 		ts.ScrollIndex = max(0, ts.ScrollIndex-1)
 	}
 	
-	// Handle category filtering with number keys
-	if inpututil.IsKeyJustPressed(ebiten.Key0) {
-		ts.CurrentFilter = "All"
-		ts.ReloadTroopNames()
-	}
-	if inpututil.IsKeyJustPressed(ebiten.Key1) {
-		ts.FilterByRarity("Common")
-	}
-	if inpututil.IsKeyJustPressed(ebiten.Key2) {
-		ts.FilterByRarity("Rare")
-	}
-	if inpututil.IsKeyJustPressed(ebiten.Key3) {
-		ts.FilterByRarity("Epic")
-	}
-	if inpututil.IsKeyJustPressed(ebiten.Key4) {
-		ts.FilterByRarity("Legendary")
+	// Handle category filtering with number keys; a hand isn't filtered
+	if !ts.ShowingHand {
+		if inpututil.IsKeyJustPressed(ebiten.Key0) {
+			ts.CurrentFilter = "All"
+			ts.ReloadTroopNames()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.Key1) {
+			ts.FilterByRarity("Common")
+		}
+		if inpututil.IsKeyJustPressed(ebiten.Key2) {
+			ts.FilterByRarity("Rare")
+		}
+		if inpututil.IsKeyJustPressed(ebiten.Key3) {
+			ts.FilterByRarity("Epic")
+		}
+		if inpututil.IsKeyJustPressed(ebiten.Key4) {
+			ts.FilterByRarity("Legendary")
+		}
 	}
 	
 	// Handle numeric troop selection (5-9 keys for first 5 troops)
@@ -167,10 +233,11 @@ func (ts *TroopSelectionSystem) Draw(screen *ebiten.Image) {
 			cardColor = color.RGBA{100, 150, 200, 255}
 		}
 		
-		// Get troop template for info
-		template, exists := TroopTemplateMap[troopName]
+		// Get the template the card summons for info. Spells and buildings
+		// in a hand have none and are drawn as plain cards.
+		template, exists := ts.Catalog.CardTroop(troopName)
 		if !exists {
-			continue
+			template = &TroopTemplate{}
 		}
 		
 		// Color based on rarity
@@ -264,7 +331,7 @@ func (ts *TroopSelectionSystem) Draw(screen *ebiten.Image) {
 		}
 		
 		// Draw troop name
-		displayName := GetTroopDisplayName(troopName)
+		displayName := GetTroopDisplayName(ts.Catalog, troopName)
 		if len(displayName) > 10 {
 			displayName = displayName[:9] + "."
 		}
@@ -275,6 +342,16 @@ func (ts *TroopSelectionSystem) Draw(screen *ebiten.Image) {
 			cardY+ts.CardHeight-15,
 		)
 		
+		// Draw elixir cost
+		if card, exists := ts.Catalog.Card(troopName); exists {
+			ebitenutil.DebugPrintAt(
+				screen,
+				fmt.Sprintf("%d", card.ElixirCost),
+				cardX+2,
+				cardY+2,
+			)
+		}
+		
 		// Add hotkey indicator
 		if i < 5 {
 			hotkey := fmt.Sprintf("%d", i+5)
@@ -305,8 +382,11 @@ func (ts *TroopSelectionSystem) Draw(screen *ebiten.Image) {
 		)
 	}
 	
-	// Draw filter info
+	// Draw filter info, or the next card when showing a hand
 	filterText := fmt.Sprintf("Filter: %s [0-4]", ts.CurrentFilter)
+	if ts.ShowingHand {
+		filterText = fmt.Sprintf("Next: %s", GetTroopDisplayName(ts.Catalog, ts.NextCard))
+	}
 	ebitenutil.DebugPrintAt(
 		screen,
 		filterText,
@@ -322,9 +402,11 @@ func (ts *TroopSelectionSystem) FilterByRarity(rarity string) {
 	
 	// Create filtered list
 	filteredNames := make([]string, 0)
-	for name, template := range TroopTemplateMap {
-		// Skip NOTINUSE troops
-		if strings.Contains(name, "NOTINUSE") {
+	for _, name := range ts.Catalog.CardNames() {
+		template, isTroop := ts.Catalog.CardTroop(name)
+		
+		// Skip spells and NOTINUSE troops
+		if !isTroop || strings.Contains(name, "NOTINUSE") {
 			continue
 		}
 		
@@ -347,20 +429,25 @@ func (ts *TroopSelectionSystem) FilterByRarity(rarity string) {
 	}
 }
 
-// Add this function to deploy the selected troop at a specified location
-func (ts *TroopSelectionSystem) DeploySelectedTroop(game *Game, col, row, team int) {
+// DeploySelectedTroop queues a deploy command for the selected card on
+// behalf of a player, or a cast for a spell card. The simulation validates
+// and applies it at the next tick boundary.
+func (ts *TroopSelectionSystem) DeploySelectedTroop(game *Game, col, row, player int) {
 	if ts.SelectedTroop == "" {
 		return
 	}
 	
-	// Get grid position
-	pos := game.Grid.CellToPosition(col, row)
-	
-	// Spawn the troop from the template
-	err := SpawnExtendedTroop(ts.SelectedTroop, pos.X, pos.Y, team, game)
-	if err != nil {
-		fmt.Printf("Error spawning troop: %v\n", err)
-	}
+	cmdType := CommandDeployCard
+	if card, exists := ts.Catalog.Card(ts.SelectedTroop); exists && card.IsSpell() {
+		cmdType = CommandCastSpell
+	}
+	game.SubmitCommand(Command{
+		Type:   cmdType,
+		Player: player,
+		Card:   ts.SelectedTroop,
+		Col:    col,
+		Row:    row,
+	})
 }
 
 // Helper min/max functions
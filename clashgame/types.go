@@ -3,6 +3,8 @@ package clashgame
 
 import (
 	"image/color"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,11 +29,10 @@ const (
     MAP_WIDTH_RATIO  = 0.7
     MAP_HEIGHT_RATIO = 1
 
+    // Grid size of the classic arena; other arenas bring their own
     GridColumns = 36
     GridRows    = 64
 
-    DURATION = 10
-
     // Building dimensions
     kingBuildingWidth = 6.0 
     kingBuildingHeight = 6.0 
@@ -50,6 +51,13 @@ type GridSystem struct {
 	ShowGrid   bool
 	CellTypes  [][]int // Store the type of each cell
 	TileMap    *TileMap // Add tilemap field
+	Occupied   [][]int  // Number of buildings covering each cell
+	ObstacleVersion int // Bumped whenever Occupied changes, invalidating cached paths
+	Columns    int      // Grid size, from the arena
+	Rows       int
+	RiverStartRow int   // First and last river row; ground troops can't see across
+	RiverEndRow   int
+	Bridges    []Bridge
 }
 
 const (
@@ -61,7 +69,9 @@ const (
     MinVelocityThreshold = 0.01    // Minimum velocity to prevent micro-jitters
 )
 
-// Add this struct to your Troop type (or modify your existing one)
+// TroopPositionHistory is a ring of the positions a troop ended its latest
+// sim steps at, which frames drawn between steps interpolate, see
+// RecordPositionHistory
 type TroopPositionHistory struct {
 	Positions []Position
 	Index     int
@@ -89,6 +99,8 @@ type Troop struct {
     IsNearTarget  bool
     LastTargetChange int
     ID            int
+    Level         int // Card level the troop was deployed at
+    Mass          float64 // Collision mass, see TroopMass
     // Movement smoothing fields
     PositionHistory TroopPositionHistory
     IsAttacking   bool
@@ -97,22 +109,47 @@ type Troop struct {
     TargetVelocity Position   // Desired velocity vector
     MaxAcceleration float64   // How quickly the troop can change direction
     TargetBuilding *Building // Current target building
+    TargetTroopID int       // Enemy troop the troop is locked onto (0 if none)
+    IgnoredTargets map[int]bool // Troop IDs never targeted again (SpecialAttacksToIgnoreList)
+    Path          []Position // Cached route, see troopPath
+    PathGoal      [2]int     // Cell the cached route leads to
+    PathVersion   int        // Grid ObstacleVersion the route was planned on
+    Template      *TroopTemplate // Template the troop was spawned from (nil for custom troops)
+    GroupID       int       // Shared by units deployed from the same card (0 if none)
+    DeployTicks   int       // Ticks left until the troop lands and can act
+    LifeTicks     int       // Ticks left until the troop expires (0 = no limit)
+    DeathHandled  bool      // Death effects have already run
+    Card          string    // Card the troop was deployed from ("" for debug spawns)
+    Owner         int       // Player who deployed the card, credited with its damage
 }
 
+// Game holds the full match state. The simulation goroutine started by
+// StartGameLoop and Ebiten's Update/Draw both touch it, so every access to the
+// mutable fields below goes through mu: Tick and the timer hold it on the
+// simulation side, Update and Draw hold it on the Ebiten side.
 type Game struct {
-    Players            [2]Player
+    mu                 sync.Mutex // Guards all simulation state below
+    Config             GameConfig // Settings the match was created with
+    Teams              [NumTeams]Team // Crown towers, shared by each team's players
+    Players            []Player       // Everyone playing, see PlayerTeam
     Troops             []Troop
     Projectiles        []Projectile
+    Effects            []Effect
+    DeployedBuildings  []*Building // Buildings placed from cards, both teams
     GameTime           int
     Running            bool
     Ticker             *time.Ticker
     StopChannel        chan bool
     ShowDebugGrid      bool
     Grid               *GridSystem
+    Arena              *Arena  // Layout the match is played on
     LeftMousePressed   bool
     RightMousePressed  bool
     TroopSelection     *TroopSelectionSystem
     TroopDrawer        *EnhancedTroopDrawer
+    Editor             *TilemapEditor // Map editor, nil when not available
+    Bots               []*Bot  // Computer players, see AddBot
+    Server             *Server // Network players, nil for a local game
     ShowTroopInfo      bool
     SelectedTroopID    int
     ShowCSVPath        bool    // New field to control CSV path display
@@ -121,18 +158,49 @@ type Game struct {
     // Building map for quick access (key: building ID, value: reference to building)
     BuildingMap        map[int]*Building
     NextBuildingID     int // To assign unique IDs to buildings
+    NextTroopID        int // To assign unique IDs to troops within this game
+    NextGroupID        int // To assign group IDs to multi-unit card deploys
+    
+    // Immutable template data this match plays with
+    Catalog            *Catalog
+    nextCatalog        atomic.Pointer[Catalog] // Swapped in at the next tick, see QueueCatalog
+    
+    // Player actions waiting for the simulation, and the ones already applied
+    Commands           *CommandQueue
+    CommandLog         []Command
+    Winner             int // Winning team, or NoWinner while undecided or drawn
+    Phase              MatchPhase // Regulation, overtime or ended, see UpdateMatchResult
+    
+    // Damage each player's cards have dealt, see recordDamage
+    DamageByCard       []map[string]int
+    
+    // When the latest tick finished, see renderAlpha
+    lastTickAt         time.Time
+}
+
+// Team is one side of the match. Its players defend the same crown towers.
+type Team struct {
+    KingBuilding  KingBuilding
+    Buildings     []Building // Princess towers, one per lane
+    Color         color.RGBA
 }
 
-// Add decks to the Player struct
+// Player is one person or bot in the match, with their own elixir, deck and
+// hand
 type Player struct {
     Id            uuid.UUID
+    Team          int       // Side the player is on, see PlayerTeam
     Elixir        float64
-    KingBuilding     KingBuilding
-    Buildings        []Building
     Color         color.RGBA
     NextCard      int       // Index of next card to draw
     ElixirMax     int       // Maximum elixir capacity
     ElixirGenRate float64   // Elixir generated per second
+    KingLevel     int       // Decides crown tower stats
+    Deck          []DeckCard // Cards and levels brought into the match
+    Hand          []string  // Cards that can be played now, see HandSize
+    CardQueue     []string  // Cards waiting to cycle into the hand
+    LastEmote     string    // Most recent emote sent by this player
+    LastEmoteTick int       // Game tick the emote was sent on
 }
 
 // Position represents a 2D position
@@ -154,7 +222,16 @@ type Building struct {
     ProjectileType string
     LastAttack    int
     ID            int           // Unique identifier for the building
+    TargetTroopID int           // Enemy troop the building is locked onto (0 if none)
+    Name          string        // Template name for deployed buildings
+    AttackDelay   int           // Ticks between attacks (0 uses the tower default)
+    GroundOnly    bool          // Can't shoot flying troops
+    Obstacle      bool          // Footprint is currently blocking the grid
+    LifeTicks     int           // Ticks left until the building expires (0 = no limit)
+    DeployTicks   int           // Ticks left until the building is set up and can attack
     Team          int           // Team ID (0 or 1)
+    Owner         int           // Player who placed the card, see Troop.Owner
+    DamageMultiplier float64    // Level scaling of projectile damage, see ShotDamage
 }
 
 // Kingbuilding represents the main building for each player
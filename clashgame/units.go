@@ -0,0 +1,66 @@
+// units.go
+package clashgame
+
+import "math"
+
+// The template CSVs use the original game's units: distances in thousandths
+// of a tile, durations in milliseconds and speeds in tiles per minute. The
+// simulation works in grid cells and ticks. Every conversion between the
+// two goes through this file.
+
+const (
+	CellsPerTile     = 2    // An arena tile is two grid cells wide
+	dataUnitsPerTile = 1000 // CSV distances are in thousandths of a tile
+	meleeRangeTiles  = 1.5  // Longest reach that still counts as melee
+)
+
+// TilesToCells converts a distance in tiles to grid cells
+func TilesToCells(tiles float64) float64 {
+	return tiles * CellsPerTile
+}
+
+// CellsToTiles converts a distance in grid cells to tiles
+func CellsToTiles(cells float64) float64 {
+	return cells / CellsPerTile
+}
+
+// DataDistanceToCells converts a CSV distance (thousandths of a tile) to
+// grid cells, e.g. the Knight's Range of 1200 is 1.2 tiles or 2.4 cells
+func DataDistanceToCells(units float64) float64 {
+	return TilesToCells(units / dataUnitsPerTile)
+}
+
+// MillisToSeconds converts a CSV duration in milliseconds to seconds
+func MillisToSeconds(ms float64) float64 {
+	return ms / 1000
+}
+
+// SecondsToTicks converts a duration in seconds to simulation ticks
+func SecondsToTicks(seconds float64) int {
+	return int(math.Round(seconds * float64(TicksPerSecond)))
+}
+
+// TicksToSeconds converts simulation ticks to seconds of game time
+func TicksToSeconds(ticks int) float64 {
+	return float64(ticks) / float64(TicksPerSecond)
+}
+
+// SpeedToCellsPerTick converts a CSV speed in tiles per minute to grid
+// cells per tick, e.g. a medium speed of 60 is one tile per second
+func SpeedToCellsPerTick(tilesPerMinute float64) float64 {
+	return TilesToCells(tilesPerMinute/60) / float64(TicksPerSecond)
+}
+
+// IsMeleeRange reports whether an attack range in grid cells is melee
+func IsMeleeRange(rangeCells float64) bool {
+	return rangeCells <= TilesToCells(meleeRangeTiles)
+}
+
+// csvUnits are the conversions a `unit:"..."` tag can apply to a CSV int
+// column, see CSVTable.Unmarshal
+var csvUnits = map[string]func(float64) float64{
+	"distance": DataDistanceToCells, // Thousandths of a tile to grid cells
+	"ms":       MillisToSeconds,     // Milliseconds to seconds
+	"speed":    SpeedToCellsPerTick, // Tiles per minute to cells per tick
+	"percent":  func(value float64) float64 { return value / 100 },
+}
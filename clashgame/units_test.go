@@ -0,0 +1,78 @@
+// units_test.go
+package clashgame
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTilesToCells(t *testing.T) {
+	tests := []struct {
+		tiles, cells float64
+	}{
+		{0, 0},
+		{1, 2},
+		{1.2, 2.4},
+		{5.5, 11},
+		{7.5, 15},
+	}
+	for _, tt := range tests {
+		if got := TilesToCells(tt.tiles); math.Abs(got-tt.cells) > 1e-9 {
+			t.Errorf("TilesToCells(%g) = %g, want %g", tt.tiles, got, tt.cells)
+		}
+		if got := CellsToTiles(tt.cells); math.Abs(got-tt.tiles) > 1e-9 {
+			t.Errorf("CellsToTiles(%g) = %g, want %g", tt.cells, got, tt.tiles)
+		}
+	}
+}
+
+func TestSecondsToTicks(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		ticks   int
+	}{
+		{0, 0},
+		{0.04, 1},
+		{1, 25},
+		{1.2, 30},
+		{0.8, 20},
+		{180, 4500},
+	}
+	for _, tt := range tests {
+		if got := SecondsToTicks(tt.seconds); got != tt.ticks {
+			t.Errorf("SecondsToTicks(%g) = %d, want %d", tt.seconds, got, tt.ticks)
+		}
+		if got := TicksToSeconds(tt.ticks); math.Abs(got-tt.seconds) > 1e-9 {
+			t.Errorf("TicksToSeconds(%d) = %g, want %g", tt.ticks, got, tt.seconds)
+		}
+	}
+}
+
+// TestLoadedKnightStats pins the Knight's CSV values (Range 1200, HitSpeed
+// 1200, Speed 60) to the simulation units they load as
+func TestLoadedKnightStats(t *testing.T) {
+	knight, exists := testCatalog(t).Troop("Knight")
+	if !exists {
+		t.Fatal("no Knight in the embedded data")
+	}
+
+	tests := []struct {
+		stat      string
+		got, want float64
+	}{
+		{"range in tiles", CellsToTiles(knight.Range), 1.2},
+		{"range in cells", knight.Range, 2.4},
+		{"hit speed in seconds", knight.HitSpeed, 1.2},
+		{"hit speed in ticks", float64(SecondsToTicks(knight.HitSpeed)), 30},
+		{"speed in tiles per second", CellsToTiles(knight.Speed) * float64(TicksPerSecond), 1},
+		{"speed in cells per tick", knight.Speed, 0.08},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-9 {
+			t.Errorf("Knight %s = %g, want %g", tt.stat, tt.got, tt.want)
+		}
+	}
+	if !IsMeleeRange(knight.Range) {
+		t.Error("Knight range doesn't count as melee")
+	}
+}
@@ -8,18 +8,31 @@ func Distance(a, b Position) float64 {
 	return math.Sqrt(dx*dx + dy*dy)
 }
 
-
+// UpdateElixir gives every player a second's worth of elixir at the rate
+// the game mode sets for the current point in the match
 func UpdateElixir(game *Game) {
+    multiplier := game.Config.Mode.ElixirMultiplier(game.GameTime)
     for i := range game.Players {
         player := &game.Players[i]
-        player.Elixir = math.Min(player.Elixir+player.ElixirGenRate, float64(player.ElixirMax))
+        player.Elixir = math.Min(player.Elixir+player.ElixirGenRate*multiplier, float64(player.ElixirMax))
     }
 }
 
+// IsActive reports whether the match is still running. It takes the game
+// lock, so callers must not already hold it.
 func (game *Game) IsActive() bool {
+	game.mu.Lock()
+	defer game.mu.Unlock()
 	return game.Running
 }
 
+// setRunning updates the running flag under the game lock
+func (game *Game) setRunning(running bool) {
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	game.Running = running
+}
+
 // Add this helper function to check if a position is valid (not in water)
 func IsValidPosition(grid *GridSystem, position Position) bool {
 	// Convert position to grid cell
@@ -41,10 +54,7 @@ func (g *Game) PlaceExtendedTroopAtCell(col, row int, troopName string, team int
 // Useful for testing
 func (g *Game) SpawnRandomTroops(count int) {
 	// Get list of available troop names
-	troopNames := make([]string, 0, len(TroopTemplateMap))
-	for name := range TroopTemplateMap {
-		troopNames = append(troopNames, name)
-	}
+	troopNames := g.Catalog.TroopNames()
 	
 	// Helper function to get a random troop name
 	getRandomTroopName := func() string {
@@ -57,8 +67,8 @@ func (g *Game) SpawnRandomTroops(count int) {
 	// Spawn troops for team 0 (friendly)
 	for i := 0; i < count; i++ {
 		// Random position in top third of map
-		col := (g.GameTime*3 + i*5) % GridColumns
-		row := (g.GameTime + i*3) % (GridRows/3)
+		col := (g.GameTime*3 + i*5) % g.Grid.Columns
+		row := (g.GameTime + i*3) % (g.Grid.Rows/3)
 		
 		// Get random troop
 		troopName := getRandomTroopName()
@@ -70,8 +80,8 @@ func (g *Game) SpawnRandomTroops(count int) {
 	// Spawn troops for team 1 (enemy)
 	for i := 0; i < count; i++ {
 		// Random position in bottom third of map
-		col := (g.GameTime*7 + i*11) % GridColumns
-		row := GridRows - 1 - ((g.GameTime + i*7) % (GridRows/3))
+		col := (g.GameTime*7 + i*11) % g.Grid.Columns
+		row := g.Grid.Rows - 1 - ((g.GameTime + i*7) % (g.Grid.Rows/3))
 		
 		// Get random troop
 		troopName := getRandomTroopName()
@@ -86,13 +96,9 @@ func GetTroopTemplate(troop *Troop) *TroopTemplate {
 		return nil
 	}
 	
-	// Look up template by name in the global template map
-	template, exists := TroopTemplateMap[troop.Name]
-	if !exists {
-		return nil
-	}
-	
-	return template
+	// Troops keep the template they were spawned with, so stats stay
+	// consistent even if the game later switches catalogs
+	return troop.Template
 }
 
 // IsFlyingTroop checks if a troop is a flying unit
@@ -171,6 +177,13 @@ func TargetsOnlyBuildings(troop *Troop) bool {
 	return buildingTargeters[troop.Name]
 }
 
+// TargetsOnlyKingBuilding checks if a troop ignores everything but the enemy
+// king tower
+func TargetsOnlyKingBuilding(troop *Troop) bool {
+	template := GetTroopTemplate(troop)
+	return template != nil && template.TargetOnlyKingBuilding
+}
+
 // TargetsOnlyTroops checks if a troop only targets other troops
 func TargetsOnlyTroops(troop *Troop) bool {
 	template := GetTroopTemplate(troop)
@@ -253,6 +266,12 @@ func GetDeathDamage(troop *Troop) int {
 	return deathDamages[troop.Name]
 }
 
+// IsKamikaze checks if a troop dies on its first attack
+func IsKamikaze(troop *Troop) bool {
+	template := GetTroopTemplate(troop)
+	return template != nil && template.Kamikaze
+}
+
 // GetDeathDamageRadius returns the radius of death damage
 func GetDeathDamageRadius(troop *Troop) float64 {
 	template := GetTroopTemplate(troop)
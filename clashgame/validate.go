@@ -0,0 +1,281 @@
+// validate.go
+package clashgame
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+)
+
+// Kinds of template a CSV column can refer to
+const (
+	refTroop      = "troop"
+	refBuilding   = "building"
+	refProjectile = "projectile"
+	refCharacter  = "character"   // A troop or a building, like death spawns
+	refAreaEffect = "area effect" // No area effect CSV is loaded, so these aren't checked
+)
+
+// csvReference is a column naming another template
+type csvReference struct {
+	column string
+	kind   string
+}
+
+// csvLimit is the smallest raw value an int column may hold
+type csvLimit struct {
+	column string
+	min    int
+}
+
+// catalogCSV describes what validation checks in one of the template CSVs
+type catalogCSV struct {
+	kind       string
+	references []csvReference
+	limits     []csvLimit
+	rarity     bool // Rarity must be one LevelMultiplier knows
+}
+
+var (
+	troopsCSV = catalogCSV{
+		kind: refTroop,
+		references: []csvReference{
+			{"Projectile", refProjectile},
+			{"SpawnCharacter", refCharacter},
+			{"DeathSpawnCharacter", refCharacter},
+		},
+		limits: []csvLimit{
+			{"Hitpoints", 1},
+			{"Damage", 0},
+			{"HitSpeed", 0},
+			{"Speed", 0},
+			{"Range", 0},
+			{"MinimumRange", 0},
+			{"SightRange", 0},
+			{"DeployTime", 0},
+			{"LifeTime", 0},
+			{"CollisionRadius", 0},
+			{"AreaDamageRadius", 0},
+			{"Mass", 0},
+			{"DeathSpawnCount", 0},
+			{"SpawnNumber", 0},
+		},
+		rarity: true,
+	}
+	projectilesCSV = catalogCSV{
+		kind: refProjectile,
+		references: []csvReference{
+			{"SpawnAreaEffectObject", refAreaEffect},
+			{"SpawnCharacter", refCharacter},
+			{"SpawnProjectile", refProjectile},
+		},
+		limits: []csvLimit{
+			{"Speed", 0},
+			{"Damage", 0},
+			{"Radius", 0},
+			{"HomingTime", 0},
+			{"CrownTowerDamagePercent", -100},
+		},
+	}
+	buildingsCSV = catalogCSV{
+		kind: refBuilding,
+		references: []csvReference{
+			{"Projectile", refProjectile},
+			{"SpawnCharacter", refCharacter},
+			{"DeathSpawnCharacter", refCharacter},
+		},
+		limits: []csvLimit{
+			{"Hitpoints", 0},
+			{"Damage", 0},
+			{"HitSpeed", 0},
+			{"Range", 0},
+			{"DeployTime", 0},
+			{"LifeTime", 0},
+			{"CollisionRadius", 0},
+		},
+		rarity: true,
+	}
+)
+
+// ValidateCatalogCSVs checks the template CSVs LoadCatalog would load from
+// a data FS. It reports cells of the wrong type, duplicate names, values out
+// of range, references to templates that don't exist (a troop's Projectile,
+// SpawnCharacter and DeathSpawnCharacter, a projectile's SpawnProjectile,
+// ...) and built-in cards whose template is missing.
+// Where the loaders silently fall back or skip, this lists every problem;
+// an empty result means the data is consistent.
+func ValidateCatalogCSVs(fsys fs.FS) []error {
+	var problems []error
+	read := func(path string) *CSVTable {
+		table, err := ReadCSVTable(fsys, path)
+		if err != nil {
+			problems = append(problems, flattenErrors(err)...)
+		}
+		return table
+	}
+	troops := read(TroopsCSVPath)
+	projectiles := read(ProjectilesCSVPath)
+	buildings := read(BuildingsCSVPath)
+
+	// Names defined per kind, nil for a CSV that couldn't be read. The
+	// built-in projectiles always exist.
+	names := map[string]map[string]bool{
+		refTroop:      templateNames(troops),
+		refProjectile: templateNames(projectiles),
+		refBuilding:   templateNames(buildings),
+	}
+	if projectiles != nil {
+		for name := range defaultProjectileTemplates() {
+			names[refProjectile][name] = true
+		}
+	}
+
+	problems = append(problems, validateTable(troops, troopsCSV, names)...)
+	problems = append(problems, validateTable(projectiles, projectilesCSV, names)...)
+	problems = append(problems, validateTable(buildings, buildingsCSV, names)...)
+
+	// Cards whose template is missing are silently left out of the catalog.
+	// Skip the checks for a CSV that couldn't be read, it's reported already.
+	if troops != nil {
+		for _, card := range multiUnitCards {
+			if !names[refTroop][card.Troop] {
+				problems = append(problems, fmt.Errorf("card %s: unknown troop %q", card.Name, card.Troop))
+			}
+		}
+	}
+	if buildings != nil {
+		for _, card := range buildingCards {
+			if !names[refBuilding][card.Building] {
+				problems = append(problems, fmt.Errorf("card %s: unknown building %q", card.Name, card.Building))
+			}
+		}
+	}
+	if projectiles != nil {
+		for _, card := range spellCards {
+			if !names[refProjectile][card.Spell] {
+				problems = append(problems, fmt.Errorf("card %s: unknown projectile %q", card.Name, card.Spell))
+			}
+		}
+	}
+
+	return problems
+}
+
+// templateNames returns the names defined in a template CSV
+func templateNames(table *CSVTable) map[string]bool {
+	if table == nil {
+		return nil
+	}
+	names := make(map[string]bool)
+	for _, record := range table.Rows {
+		if !skipCSVRow(record) {
+			names[record[0]] = true
+		}
+	}
+	return names
+}
+
+// validateTable checks the rows of one template CSV
+func validateTable(table *CSVTable, spec catalogCSV, names map[string]map[string]bool) []error {
+	if table == nil {
+		return nil
+	}
+	var problems []error
+	problem := func(i int, column string, format string, args ...interface{}) {
+		problems = append(problems, &CSVError{Path: table.Path, Row: table.Lines[i], Column: column, Err: fmt.Errorf(format, args...)})
+	}
+
+	// Every column the checks read must exist
+	columns := []string{"Name"}
+	for _, ref := range spec.references {
+		columns = append(columns, ref.column)
+	}
+	for _, limit := range spec.limits {
+		columns = append(columns, limit.column)
+	}
+	if spec.rarity {
+		columns = append(columns, "Rarity")
+	}
+	for _, column := range columns {
+		if _, exists := table.columns[column]; !exists {
+			problems = append(problems, &CSVError{Path: table.Path, Column: column, Err: fmt.Errorf("missing column")})
+		}
+	}
+
+	firstRow := make(map[string]int)
+	for i, record := range table.Rows {
+		if skipCSVRow(record) {
+			continue
+		}
+		name := record[0]
+		if first, seen := firstRow[name]; seen {
+			problem(i, "Name", "duplicate %s %q, first defined on row %d", spec.kind, name, first)
+		} else {
+			firstRow[name] = table.Lines[i]
+		}
+
+		for _, ref := range spec.references {
+			target, _ := table.Cell(i, ref.column)
+			if target != "" && !referenceExists(names, ref.kind, target) {
+				problem(i, ref.column, "%s %s refers to unknown %s %q", spec.kind, name, ref.kind, target)
+			}
+		}
+
+		for _, limit := range spec.limits {
+			cell, _ := table.Cell(i, limit.column)
+			value, err := strconv.Atoi(cell)
+			if err != nil {
+				// Empty, or a bad int ReadCSVTable reported already
+				continue
+			}
+			if value < limit.min {
+				problem(i, limit.column, "%d is below the minimum of %d", value, limit.min)
+			}
+		}
+
+		if spec.rarity {
+			rarity, _ := table.Cell(i, "Rarity")
+			if _, known := rarityScaling[rarity]; !known {
+				problem(i, "Rarity", "unknown rarity %q", rarity)
+			}
+		}
+
+		// A minimum range past the range leaves nothing the troop can hit
+		if spec.kind == refTroop {
+			rangeCell, _ := table.Cell(i, "Range")
+			minimumCell, _ := table.Cell(i, "MinimumRange")
+			attackRange, rangeErr := strconv.Atoi(rangeCell)
+			minimum, minimumErr := strconv.Atoi(minimumCell)
+			if rangeErr == nil && minimumErr == nil && minimum > attackRange {
+				problem(i, "MinimumRange", "%d is past the troop's Range %d", minimum, attackRange)
+			}
+		}
+	}
+
+	return problems
+}
+
+// referenceExists reports whether a template of the given kind has the
+// name. References into a CSV that couldn't be read, or that isn't loaded at
+// all like area effects, aren't checked.
+func referenceExists(names map[string]map[string]bool, kind, name string) bool {
+	kinds := []string{kind}
+	switch kind {
+	case refCharacter:
+		kinds = []string{refTroop, refBuilding}
+	}
+	for _, kind := range kinds {
+		if names[kind] == nil || names[kind][name] {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenErrors splits errors.Join results back into their parts
+func flattenErrors(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
@@ -0,0 +1,95 @@
+// validate_test.go
+package clashgame
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestValidateDefaultData keeps the embedded data passing cmd/validate
+func TestValidateDefaultData(t *testing.T) {
+	for _, problem := range ValidateCatalogCSVs(DefaultData()) {
+		t.Error(problem)
+	}
+}
+
+// dataWithTroops copies the embedded data and appends a copy of the Knight
+// to troops.csv for each set of cell overrides
+func dataWithTroops(t *testing.T, rows ...map[string]string) fs.FS {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	for _, path := range []string{TroopsCSVPath, ProjectilesCSVPath, BuildingsCSVPath} {
+		data, err := fs.ReadFile(DefaultData(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys[path] = &fstest.MapFile{Data: data}
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(fsys[TroopsCSVPath].Data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := make(map[string]int)
+	for i, column := range records[0] {
+		columns[column] = i
+	}
+	var knight []string
+	for _, record := range records {
+		if record[0] == "Knight" {
+			knight = record
+		}
+	}
+	for _, overrides := range rows {
+		row := append([]string(nil), knight...)
+		for column, value := range overrides {
+			row[columns[column]] = value
+		}
+		records = append(records, row)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(records); err != nil {
+		t.Fatal(err)
+	}
+	fsys[TroopsCSVPath] = &fstest.MapFile{Data: buf.Bytes()}
+	return fsys
+}
+
+// TestValidateSeededProblems checks each kind of problem is reported once,
+// on the row and column it was seeded in
+func TestValidateSeededProblems(t *testing.T) {
+	fsys := dataWithTroops(t,
+		map[string]string{},
+		map[string]string{"Name": "BadReference", "Projectile": "NoSuchProjectile"},
+		map[string]string{"Name": "NoHitpoints", "Hitpoints": "0"},
+	)
+	problems := ValidateCatalogCSVs(fsys)
+
+	want := []struct {
+		column, text string
+	}{
+		{"Name", `duplicate troop "Knight"`},
+		{"Projectile", `unknown projectile "NoSuchProjectile"`},
+		{"Hitpoints", "0 is below the minimum of 1"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for i, w := range want {
+		var csvErr *CSVError
+		if !errors.As(problems[i], &csvErr) {
+			t.Errorf("problem %d is %T, want a *CSVError", i, problems[i])
+			continue
+		}
+		if csvErr.Path != TroopsCSVPath || csvErr.Row == 0 || csvErr.Column != w.column || !strings.Contains(csvErr.Error(), w.text) {
+			t.Errorf("problem %d = %v, want troops.csv column %s: %s", i, csvErr, w.column, w.text)
+		}
+	}
+}
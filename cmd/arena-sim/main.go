@@ -0,0 +1,374 @@
+// Command arena-sim runs headless bot-vs-bot tournaments to evaluate balance
+// changes. Every pair of entrants plays -matches seeded games, swapping sides
+// each game, spread over -workers goroutines. The results are the same for a
+// given seed no matter how many workers run them.
+//
+// Entrants come from a JSON file:
+//
+//	[{"name": "beatdown", "difficulty": "hard", "deck": ["Giant", "Musketeer", ...]}, ...]
+//
+// or, for a quick head-to-head, from the -deck0/-bot0 and -deck1/-bot1 flags.
+// Matches follow the rules of -mode; draft and random modes replace the
+// entrants' decks with ones dealt from the match seed. With -team-size 2
+// an entrant's bot plays both seats of its team in 2v2.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/basilm9/clash/clashgame"
+	"github.com/basilm9/clash/config"
+)
+
+// entrantConfig is one entrant as written in the -entrants file
+type entrantConfig struct {
+	Name       string   `json:"name"`
+	Difficulty string   `json:"difficulty"`
+	Deck       []string `json:"deck"`
+}
+
+// job is one scheduled match between two entrants
+type job struct {
+	Entrants [2]int // Entrant index playing each team
+	Seed     int64
+}
+
+// EntrantStats summarizes an entrant's results over the tournament
+type EntrantStats struct {
+	Name           string  `json:"name"`
+	Difficulty     string  `json:"difficulty"`
+	Matches        int     `json:"matches"`
+	Wins           int     `json:"wins"`
+	Losses         int     `json:"losses"`
+	Draws          int     `json:"draws"`
+	WinRate        float64 `json:"winRate"`
+	AvgCrowns      float64 `json:"avgCrowns"`
+	AvgDurationSec float64 `json:"avgDurationSec"`
+
+	crowns int
+	ticks  int
+}
+
+// CardStats is the damage one entrant's card dealt over the tournament
+type CardStats struct {
+	Entrant           string  `json:"entrant"`
+	Card              string  `json:"card"`
+	TotalDamage       int     `json:"totalDamage"`
+	AvgDamagePerMatch float64 `json:"avgDamagePerMatch"`
+}
+
+// Report is everything the tournament outputs
+type Report struct {
+	Matches        int            `json:"matches"`
+	AvgDurationSec float64        `json:"avgDurationSec"`
+	Entrants       []EntrantStats `json:"entrants"`
+	Cards          []CardStats    `json:"cards"`
+}
+
+func main() {
+	configPath := flag.String("config", "", "JSON config file with the match settings (default: $"+config.EnvConfigPath+")")
+	modeName := flag.String("mode", "", "game mode: "+strings.Join(clashgame.GameModeNames(), ", ")+" (default: the config's mode)")
+	teamSize := flag.Int("team-size", 0, "players per team; each entrant's bot plays all of its team (default: the config's team size)")
+	dataDir := flag.String("data", "", "data directory laid out like clashgame/ (default: the config's dataDir, else the embedded data)")
+	arenaPath := flag.String("arena", clashgame.ClassicArenaPath, "arena JSON file inside the data directory")
+	entrantsPath := flag.String("entrants", "", "JSON file listing the entrants (overrides -deck0/-deck1)")
+	deck0 := flag.String("deck0", "", "comma-separated deck for entrant 0 (default: the bot deck)")
+	deck1 := flag.String("deck1", "", "comma-separated deck for entrant 1 (default: the bot deck)")
+	bot0 := flag.String("bot0", "medium", "bot difficulty for entrant 0")
+	bot1 := flag.String("bot1", "medium", "bot difficulty for entrant 1")
+	matches := flag.Int("matches", 100, "matches per pair of entrants")
+	seed := flag.Int64("seed", 1, "seed of the first match")
+	workers := flag.Int("workers", runtime.NumCPU(), "matches simulated in parallel")
+	maxSeconds := flag.Int("max-seconds", 0, "game seconds before a match is cut short and decided on crowns (default: the mode's full length)")
+	format := flag.String("format", "csv", "output format: csv or json")
+	outPath := flag.String("out", "", "output file (default: stdout)")
+	verbose := flag.Bool("v", false, "keep the simulation's log output")
+	flag.Parse()
+
+	if *format != "csv" && *format != "json" {
+		log.Fatalf("unknown format %q, want csv or json", *format)
+	}
+	if *matches <= 0 || *workers <= 0 {
+		log.Fatal("-matches and -workers must be positive")
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("Failed to create output: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	// The simulation logs every hit; thousands of games of that would bury
+	// the report
+	if !*verbose {
+		clashgame.LogOutput = io.Discard
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *dataDir == "" {
+		*dataDir = cfg.DataDir
+	}
+	gameConfig := cfg.GameConfig()
+	if *modeName != "" {
+		if gameConfig.Mode, err = clashgame.ParseGameMode(*modeName); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *teamSize > 0 {
+		gameConfig.TeamSize = *teamSize
+	}
+
+	data := clashgame.OpenData(*dataDir)
+	catalog, err := clashgame.LoadCatalog(data)
+	if err != nil {
+		log.Fatalf("Failed to load catalog: %v", err)
+	}
+	arena, err := clashgame.LoadArena(data, *arenaPath)
+	if err != nil {
+		log.Fatalf("Failed to load arena: %v", err)
+	}
+
+	var configs []entrantConfig
+	if *entrantsPath != "" {
+		configs, err = loadEntrants(*entrantsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		configs = []entrantConfig{
+			{Name: "entrant0", Difficulty: *bot0, Deck: splitDeck(*deck0)},
+			{Name: "entrant1", Difficulty: *bot1, Deck: splitDeck(*deck1)},
+		}
+	}
+	entrants, err := buildEntrants(catalog, configs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jobs := schedule(len(entrants), *matches, *seed)
+	log.Printf("Simulating %d %s %dv%d matches between %d entrants on %d workers", len(jobs), gameConfig.Mode.Name, gameConfig.TeamSize, gameConfig.TeamSize, len(entrants), *workers)
+	results := run(catalog, arena, gameConfig, entrants, jobs, *workers, *maxSeconds*clashgame.TicksPerSecond)
+
+	report := buildReport(entrants, jobs, results)
+	if *format == "json" {
+		err = writeJSON(out, report)
+	} else {
+		err = writeCSV(out, report)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
+
+// loadEntrants reads the entrant list from a JSON file
+func loadEntrants(path string) ([]entrantConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entrants: %v", err)
+	}
+	var configs []entrantConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse entrants %s: %v", path, err)
+	}
+	return configs, nil
+}
+
+// splitDeck parses a comma-separated card list
+func splitDeck(list string) []string {
+	var deck []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			deck = append(deck, name)
+		}
+	}
+	return deck
+}
+
+// buildEntrants checks every entrant's difficulty and cards against the
+// catalog
+func buildEntrants(catalog *clashgame.Catalog, configs []entrantConfig) ([]clashgame.SimEntrant, error) {
+	if len(configs) < 2 {
+		return nil, fmt.Errorf("need at least 2 entrants, got %d", len(configs))
+	}
+
+	entrants := make([]clashgame.SimEntrant, len(configs))
+	for i, config := range configs {
+		if config.Name == "" {
+			config.Name = fmt.Sprintf("entrant%d", i)
+		}
+		difficulty, err := clashgame.ParseBotDifficulty(config.Difficulty)
+		if err != nil {
+			return nil, fmt.Errorf("entrant %s: %v", config.Name, err)
+		}
+		for _, card := range config.Deck {
+			if _, exists := catalog.Card(card); !exists {
+				return nil, fmt.Errorf("entrant %s: unknown card %q", config.Name, card)
+			}
+		}
+		entrants[i] = clashgame.SimEntrant{Name: config.Name, Difficulty: difficulty, Deck: config.Deck}
+	}
+	return entrants, nil
+}
+
+// schedule pairs every entrant with every other for n matches each, swapping
+// sides every match so neither keeps the top or bottom half
+func schedule(entrants, n int, seed int64) []job {
+	var jobs []job
+	for a := 0; a < entrants; a++ {
+		for b := a + 1; b < entrants; b++ {
+			for i := 0; i < n; i++ {
+				match := job{Entrants: [2]int{a, b}, Seed: seed + int64(len(jobs))}
+				if i%2 == 1 {
+					match.Entrants = [2]int{b, a}
+				}
+				jobs = append(jobs, match)
+			}
+		}
+	}
+	return jobs
+}
+
+// run simulates the jobs on a pool of workers. Results are stored by job
+// index, so the order they finish in doesn't matter.
+func run(catalog *clashgame.Catalog, arena *clashgame.Arena, gameConfig clashgame.GameConfig, entrants []clashgame.SimEntrant, jobs []job, workers, maxTicks int) []clashgame.SimResult {
+	results := make([]clashgame.SimResult, len(jobs))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				match := jobs[i]
+				sides := [2]clashgame.SimEntrant{entrants[match.Entrants[0]], entrants[match.Entrants[1]]}
+				results[i] = clashgame.SimulateMatch(catalog, arena, gameConfig, sides, match.Seed, maxTicks)
+			}
+		}()
+	}
+
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// buildReport totals the results per entrant and per card
+func buildReport(entrants []clashgame.SimEntrant, jobs []job, results []clashgame.SimResult) Report {
+	stats := make([]EntrantStats, len(entrants))
+	for i, entrant := range entrants {
+		stats[i].Name = entrant.Name
+		stats[i].Difficulty = entrant.Difficulty.String()
+	}
+	damage := make([]map[string]int, len(entrants))
+
+	totalTicks := 0
+	for i, result := range results {
+		totalTicks += result.Ticks
+		for team, index := range jobs[i].Entrants {
+			entrant := &stats[index]
+			entrant.Matches++
+			entrant.crowns += result.Crowns[team]
+			entrant.ticks += result.Ticks
+			switch result.Winner {
+			case clashgame.NoWinner:
+				entrant.Draws++
+			case team:
+				entrant.Wins++
+			default:
+				entrant.Losses++
+			}
+
+			if damage[index] == nil {
+				damage[index] = make(map[string]int)
+			}
+			for card, amount := range result.DamageByCard[team] {
+				damage[index][card] += amount
+			}
+		}
+	}
+
+	report := Report{Matches: len(results), Entrants: stats}
+	if len(results) > 0 {
+		report.AvgDurationSec = ticksToSeconds(totalTicks) / float64(len(results))
+	}
+	for i := range stats {
+		entrant := &stats[i]
+		if entrant.Matches == 0 {
+			continue
+		}
+		matches := float64(entrant.Matches)
+		entrant.WinRate = float64(entrant.Wins) / matches
+		entrant.AvgCrowns = float64(entrant.crowns) / matches
+		entrant.AvgDurationSec = ticksToSeconds(entrant.ticks) / matches
+
+		cards := make([]string, 0, len(damage[i]))
+		for card := range damage[i] {
+			cards = append(cards, card)
+		}
+		sort.Strings(cards)
+		for _, card := range cards {
+			report.Cards = append(report.Cards, CardStats{
+				Entrant:           entrant.Name,
+				Card:              card,
+				TotalDamage:       damage[i][card],
+				AvgDamagePerMatch: float64(damage[i][card]) / matches,
+			})
+		}
+	}
+	return report
+}
+
+// ticksToSeconds converts simulation ticks to game seconds
+func ticksToSeconds(ticks int) float64 {
+	return float64(ticks) / float64(clashgame.TicksPerSecond)
+}
+
+// writeJSON writes the report as one indented JSON document
+func writeJSON(out io.Writer, report Report) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// writeCSV writes the entrant table, a blank line, then the card table
+func writeCSV(out io.Writer, report Report) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"entrant", "difficulty", "matches", "wins", "losses", "draws", "win_rate", "avg_crowns", "avg_duration_sec"})
+	for _, e := range report.Entrants {
+		w.Write([]string{
+			e.Name, e.Difficulty,
+			strconv.Itoa(e.Matches), strconv.Itoa(e.Wins), strconv.Itoa(e.Losses), strconv.Itoa(e.Draws),
+			clashgame.FormatStat(e.WinRate), clashgame.FormatStat(e.AvgCrowns), clashgame.FormatStat(e.AvgDurationSec),
+		})
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+
+	w.Write([]string{"entrant", "card", "total_damage", "avg_damage_per_match"})
+	for _, c := range report.Cards {
+		w.Write([]string{c.Entrant, c.Card, strconv.Itoa(c.TotalDamage), clashgame.FormatStat(c.AvgDamagePerMatch)})
+	}
+	w.Flush()
+	return w.Error()
+}
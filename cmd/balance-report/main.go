@@ -0,0 +1,117 @@
+// Command balance-report prints the derived combat stats of every troop and
+// building card: DPS, DPS per elixir, effective HP, time to kill each crown
+// tower and air/ground coverage, flagging cards far from the average. Given
+// -base-data it also lists the stat changes between two versions of the CSVs,
+// which is what a balance patch introduces.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/basilm9/clash/clashgame"
+)
+
+// Report is everything the tool outputs
+type Report struct {
+	Level     int                      `json:"level"`
+	KingLevel int                      `json:"kingLevel"`
+	Cards     []clashgame.CardBalance  `json:"cards"`
+	Deltas    []clashgame.BalanceDelta `json:"deltas,omitempty"`
+}
+
+func main() {
+	dataDir := flag.String("data", "", "data directory laid out like clashgame/ (default: $"+clashgame.DataDirEnv+", else the embedded data)")
+	baseDir := flag.String("base-data", "", "data directory with the CSVs to diff against")
+	level := flag.Int("level", clashgame.TournamentLevelCap, "card level to compare at")
+	kingLevel := flag.Int("king-level", clashgame.DefaultKingLevel, "king level of the crown towers")
+	threshold := flag.Float64("outlier", 2, "standard deviations from the average that count as an outlier")
+	format := flag.String("format", "csv", "output format: csv or json")
+	outPath := flag.String("out", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if *format != "csv" && *format != "json" {
+		log.Fatalf("unknown format %q, want csv or json", *format)
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("Failed to create output: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	// Loading logs data warnings; keep them out of the report
+	clashgame.LogOutput = io.Discard
+	catalog, err := clashgame.LoadCatalog(clashgame.OpenData(clashgame.DataDir(*dataDir)))
+	if err != nil {
+		log.Fatalf("Failed to load catalog: %v", err)
+	}
+	var base *clashgame.Catalog
+	if *baseDir != "" {
+		if base, err = clashgame.LoadCatalog(clashgame.OpenData(*baseDir)); err != nil {
+			log.Fatalf("Failed to load catalog from %s: %v", *baseDir, err)
+		}
+	}
+
+	report := Report{
+		Level:     *level,
+		KingLevel: *kingLevel,
+		Cards:     clashgame.AnalyzeBalance(catalog, *level, *kingLevel),
+	}
+	clashgame.FlagBalanceOutliers(report.Cards, *threshold)
+	if base != nil {
+		report.Deltas = clashgame.DiffBalance(clashgame.AnalyzeBalance(base, *level, *kingLevel), report.Cards)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = writeCSV(out, report, base != nil)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
+
+// writeCSV writes the card table and, when diffing, a blank line and the
+// delta table
+func writeCSV(out io.Writer, report Report, diff bool) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{
+		"card", "kind", "elixir", "units", "hit_damage", "hit_speed", "dps", "dps_per_elixir",
+		"effective_hp", "hp_per_elixir", "king_ttk", "princess_ttk", "coverage", "flying", "splash", "outliers",
+	})
+	for _, c := range report.Cards {
+		w.Write([]string{
+			c.Card, c.Kind, strconv.Itoa(c.Elixir), strconv.Itoa(c.Units),
+			strconv.Itoa(c.HitDamage), clashgame.FormatStat(c.HitSpeed), clashgame.FormatStat(c.DPS), clashgame.FormatStat(c.DPSPerElixir),
+			strconv.Itoa(c.EffectiveHP), clashgame.FormatStat(c.HPPerElixir), clashgame.FormatStat(c.KingTTK), clashgame.FormatStat(c.PrincessTTK),
+			c.Coverage(), strconv.FormatBool(c.Flying), strconv.FormatBool(c.Splash), strings.Join(c.Outliers, "; "),
+		})
+	}
+	w.Flush()
+	if !diff {
+		return w.Error()
+	}
+
+	fmt.Fprintln(out)
+	w.Write([]string{"card", "stat", "old", "new", "percent"})
+	for _, d := range report.Deltas {
+		w.Write([]string{d.Card, d.Stat, clashgame.FormatStat(d.Old), clashgame.FormatStat(d.New), clashgame.FormatStat(d.Percent)})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,34 @@
+// Command validate checks the template CSVs for problems the loaders would
+// skip over or paper up: cells of the wrong type, duplicate names, values
+// out of range, references to templates that don't exist and built-in cards
+// without their template. It prints one problem per line and exits with
+// status 1 if there are any, so it can gate CSV changes in CI.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/basilm9/clash/clashgame"
+)
+
+func main() {
+	dataDir := flag.String("data", "", "data directory laid out like clashgame/ (default: $"+clashgame.DataDirEnv+", else the embedded data)")
+	flag.Parse()
+
+	dir := clashgame.DataDir(*dataDir)
+	problems := clashgame.ValidateCatalogCSVs(clashgame.OpenData(dir))
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+
+	if dir == "" {
+		dir = "embedded data"
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found in %s\n", len(problems), dir)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%s is valid\n", dir)
+}
@@ -0,0 +1,215 @@
+// Package config loads the settings of the game binaries: defaults, then a
+// JSON file, then environment variable overrides, then validation. A file
+// only needs the settings it changes:
+//
+//	{
+//	  "port": 9000,
+//	  "dataDir": "clashgame",
+//	  "window": {"width": 588, "height": 843, "title": "Tower Defense Game"},
+//	  "game": {"mode": "double", "teamSize": 2, "levelCap": 11, "matchSeconds": 600, "tickMillis": 40, "startingElixir": 5, "maxElixir": 10, "elixirPerSecond": 0.2}
+//	}
+//
+// Every setting except the window can also be set from the environment, see
+// the Env constants.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/basilm9/clash/clashgame"
+)
+
+// Environment variables that override the file
+const (
+	EnvConfigPath      = "CONFIG_PATH" // Config file to load when none is given
+	EnvPort            = "PORT"
+	EnvDataDir         = clashgame.DataDirEnv
+	EnvMode            = "CLASH_MODE"
+	EnvSeed            = "CLASH_SEED"
+	EnvTeamSize        = "CLASH_TEAM_SIZE"
+	EnvLevelCap        = "CLASH_LEVEL_CAP"
+	EnvMatchSeconds    = "CLASH_MATCH_SECONDS"
+	EnvTickMillis      = "CLASH_TICK_MILLIS"
+	EnvStartingElixir  = "CLASH_STARTING_ELIXIR"
+	EnvMaxElixir       = "CLASH_MAX_ELIXIR"
+	EnvElixirPerSecond = "CLASH_ELIXIR_PER_SECOND"
+)
+
+// Config is everything a game binary can be configured with
+type Config struct {
+	Port    int          `json:"port"`    // TCP port network players join on, 0 for bots only, see clashgame.Server
+	DataDir string       `json:"dataDir"` // External data directory, "" for the embedded data
+	Window  WindowConfig `json:"window"`
+	Game    GameSettings `json:"game"`
+}
+
+// WindowConfig sizes the game window. The arena is drawn at its own size
+// and scaled to fit.
+type WindowConfig struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Title  string `json:"title"`
+}
+
+// GameSettings is clashgame.GameConfig in units that are easy to write
+type GameSettings struct {
+	Mode            string  `json:"mode"`     // Name of a clashgame game mode, like "classic"
+	Seed            int64   `json:"seed"`     // Seed of draft and random decks, 0 for a new one each game
+	TeamSize        int     `json:"teamSize"` // Players per team, 2 for 2v2
+	LevelCap        int     `json:"levelCap"` // Highest card and king level, 0 for no cap
+	MatchSeconds    float64 `json:"matchSeconds"`
+	TickMillis      float64 `json:"tickMillis"`
+	StartingElixir  float64 `json:"startingElixir"`
+	MaxElixir       int     `json:"maxElixir"`
+	ElixirPerSecond float64 `json:"elixirPerSecond"`
+}
+
+// Default returns the settings used when nothing overrides them
+func Default() Config {
+	game := clashgame.DefaultGameConfig()
+	return Config{
+		Window: WindowConfig{
+			Width:  1177 / 2,
+			Height: 1687 / 2,
+			Title:  "Tower Defense Game",
+		},
+		Game: GameSettings{
+			Mode:            game.Mode.Name,
+			TeamSize:        game.TeamSize,
+			LevelCap:        game.LevelCap,
+			MatchSeconds:    game.MatchDuration.Seconds(),
+			TickMillis:      float64(game.TickInterval) / float64(time.Millisecond),
+			StartingElixir:  game.StartingElixir,
+			MaxElixir:       game.MaxElixir,
+			ElixirPerSecond: game.ElixirGenRate,
+		},
+	}
+}
+
+// Load reads the config file at path over the defaults, applies the
+// environment overrides and validates the result. An empty path falls back
+// to $CONFIG_PATH, and to the defaults alone when that is unset too.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path == "" {
+		path = os.Getenv(EnvConfigPath)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read config: %v", err)
+		}
+		// Unknown keys are almost always typos of a setting
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&cfg); err != nil {
+			return Config{}, fmt.Errorf("failed to parse config %s: %v", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides settings from the environment and reports every
+// variable that doesn't parse
+func (c *Config) applyEnv() error {
+	envString(EnvDataDir, &c.DataDir)
+	envString(EnvMode, &c.Game.Mode)
+	return errors.Join(
+		envInt(EnvPort, &c.Port),
+		envInt(EnvSeed, &c.Game.Seed),
+		envInt(EnvTeamSize, &c.Game.TeamSize),
+		envInt(EnvLevelCap, &c.Game.LevelCap),
+		envFloat(EnvMatchSeconds, &c.Game.MatchSeconds),
+		envFloat(EnvTickMillis, &c.Game.TickMillis),
+		envFloat(EnvStartingElixir, &c.Game.StartingElixir),
+		envInt(EnvMaxElixir, &c.Game.MaxElixir),
+		envFloat(EnvElixirPerSecond, &c.Game.ElixirPerSecond),
+	)
+}
+
+// envString sets a setting from a variable, if set
+func envString(name string, setting *string) {
+	if value := os.Getenv(name); value != "" {
+		*setting = value
+	}
+}
+
+// envInt sets an integer setting from a variable, if set
+func envInt[T int | int64](name string, setting *T) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s=%q is not a whole number", name, value)
+	}
+	*setting = T(parsed)
+	return nil
+}
+
+// envFloat sets a numeric setting from a variable, if set
+func envFloat(name string, setting *float64) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%s=%q is not a number", name, value)
+	}
+	*setting = parsed
+	return nil
+}
+
+// Validate reports every setting that is out of range
+func (c Config) Validate() error {
+	var errs []error
+	if c.Port < 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 0 and 65535, got %d", c.Port))
+	}
+	if c.Window.Width <= 0 || c.Window.Height <= 0 {
+		errs = append(errs, fmt.Errorf("window must be at least 1x1, got %dx%d", c.Window.Width, c.Window.Height))
+	}
+	if _, err := clashgame.ParseGameMode(c.Game.Mode); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.GameConfig().Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// GameConfig converts the game settings for clashgame.NewGame. Load has
+// already rejected unknown modes; an unvalidated Config falls back to the
+// classic mode for them.
+func (c Config) GameConfig() clashgame.GameConfig {
+	mode, err := clashgame.ParseGameMode(c.Game.Mode)
+	if err != nil {
+		mode = clashgame.ClassicMode
+	}
+	return clashgame.GameConfig{
+		Mode:           mode,
+		TeamSize:       c.Game.TeamSize,
+		LevelCap:       c.Game.LevelCap,
+		MatchDuration:  time.Duration(c.Game.MatchSeconds * float64(time.Second)),
+		TickInterval:   time.Duration(c.Game.TickMillis * float64(time.Millisecond)),
+		StartingElixir: c.Game.StartingElixir,
+		MaxElixir:      c.Game.MaxElixir,
+		ElixirGenRate:  c.Game.ElixirPerSecond,
+		Seed:           c.Game.Seed,
+	}
+}
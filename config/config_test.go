@@ -0,0 +1,57 @@
+// config_test.go
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLoadEnv checks the environment overrides the defaults and that every
+// bad variable is reported
+func TestLoadEnv(t *testing.T) {
+	t.Setenv(EnvConfigPath, "")
+	t.Setenv(EnvPort, "9000")
+	t.Setenv(EnvMode, "double")
+	t.Setenv(EnvTeamSize, "2")
+	t.Setenv(EnvElixirPerSecond, "0.2")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 9000 || cfg.Game.Mode != "double" || cfg.Game.TeamSize != 2 || cfg.Game.ElixirPerSecond != 0.2 {
+		t.Errorf("environment not applied: %+v", cfg)
+	}
+
+	t.Setenv(EnvPort, "http")
+	t.Setenv(EnvTickMillis, "fast")
+	_, err = Load("")
+	if err == nil {
+		t.Fatal("bad variables were accepted")
+	}
+	for _, name := range []string{EnvPort, EnvTickMillis} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error doesn't name %s: %v", name, err)
+		}
+	}
+}
+
+// TestValidatePort checks the port range, with 0 turning network play off
+func TestValidatePort(t *testing.T) {
+	for _, tt := range []struct {
+		port int
+		ok   bool
+	}{
+		{0, true},
+		{9000, true},
+		{65535, true},
+		{-1, false},
+		{65536, false},
+	} {
+		cfg := Default()
+		cfg.Port = tt.port
+		if err := cfg.Validate(); (err == nil) != tt.ok {
+			t.Errorf("port %d: got %v", tt.port, err)
+		}
+	}
+}
@@ -1,69 +1,119 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
-	"os"
-	"path/filepath"
+	"time"
 
 	"github.com/basilm9/clash/clashgame"
+	"github.com/basilm9/clash/config"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-const (
-	// Screen dimensions
-	screenWidth = 1177 / 2
-	screenHeight = 1687 / 2
-	DURATION = 10
-)
-
 func main() {
-    // Set window size and title
-    ebiten.SetWindowSize(screenWidth, screenHeight)
-    ebiten.SetWindowTitle("Tower Defense Game")
+    // Settings come from the defaults, then -config or $CONFIG_PATH, then
+    // the environment
+    configPath := flag.String("config", "", "JSON config file (default: $"+config.EnvConfigPath+")")
+    // Game data is embedded in the binary; -data or $CLASH_DATA_DIR points
+    // at a directory laid out like clashgame/ to play with edited files
+    dataDir := flag.String("data", "", "data directory laid out like clashgame/ (default: the config's dataDir, else the embedded data)")
+    // With -listen the seats other than yours are played over the network
+    // instead of by bots
+    listen := flag.String("listen", "", "TCP address network players join on, e.g. :9000 (default: the config's port, else bots play every other seat)")
+    flag.Parse()
 
-    // Get executable directory
-    exePath, err := os.Executable()
+    cfg, err := config.Load(*configPath)
     if err != nil {
-        log.Fatalf("Failed to get executable path: %v", err)
+        log.Fatal(err)
     }
-    exeDir := filepath.Dir(exePath)
 
-    // Path to troops.csv, projectiles.csv, and tilemap.csv files
-    troopsCsvPath := filepath.Join(exeDir, "clashgame/csv/troops.csv")
-    projectilesCsvPath := filepath.Join(exeDir, "clashgame/csv/projectiles.csv")
-    tilemapCsvPath := filepath.Join(exeDir, "clashgame/csv/tilemap.csv")
+    if *listen == "" && cfg.Port != 0 {
+        *listen = fmt.Sprintf(":%d", cfg.Port)
+    }
+
+    // Set window size and title
+    ebiten.SetWindowSize(cfg.Window.Width, cfg.Window.Height)
+    ebiten.SetWindowTitle(cfg.Window.Title)
+
+    dir := *dataDir
+    if dir == "" {
+        dir = cfg.DataDir
+    }
+    data := clashgame.OpenData(dir)
 
-    // Initialize projectile system
-    err = clashgame.LoadProjectileTemplates(projectilesCsvPath)
+    // Load the template catalog once; every game created from it shares the data
+    catalog, err := clashgame.LoadCatalog(data)
     if err != nil {
-        clashgame.InitializeProjectileSystem()
+        log.Printf("Failed to load catalog, using the embedded data: %v", err)
+        if catalog, err = clashgame.LoadCatalog(clashgame.DefaultData()); err != nil {
+            log.Fatalf("Failed to load the embedded catalog: %v", err)
+        }
     }
 
-    // Initialize the troop system by loading templates from CSV
-    err = clashgame.InitializeTroopSystem(troopsCsvPath)
+    // The arena brings its own tilemap, towers, bridges and deploy zones
+    arena, err := clashgame.LoadArena(data, clashgame.ClassicArenaPath)
     if err != nil {
-        clashgame.InitializeWithDefaultTroops()
+        log.Printf("Failed to load arena, using the classic layout: %v", err)
+        arena = clashgame.DefaultArena()
+    }
+    // The map editor can only save back into a data directory
+    arena.SaveDir = dir
+
+    // Create the game; without a configured seed every game deals new
+    // draft and random decks
+    gameConfig := cfg.GameConfig()
+    if gameConfig.Seed == 0 {
+        gameConfig.Seed = time.Now().UnixNano()
     }
+    game := clashgame.NewGame(catalog, arena, gameConfig)
 
-    // Create the game
-    game := clashgame.NewGame()
+    // You play player 0; bots or network players play your teammates in
+    // 2v2 and the enemy team. Right-clicking still deploys for player 1.
+    var seats []int
+    for player := 1; player < len(game.Players); player++ {
+        seats = append(seats, player)
+    }
+    if *listen != "" {
+        game.Server = clashgame.NewServer(game, seats)
+        go func() {
+            if err := game.Server.ListenAndServe(*listen); err != nil {
+                log.Fatalf("Network play: %v", err)
+            }
+        }()
+        defer game.Server.Close()
+    } else {
+        for _, player := range seats {
+            game.AddBot(clashgame.NewBot(player, clashgame.BotMedium, gameConfig.Seed+int64(player)))
+        }
+    }
 
     // Set the global game instance
     clashgame.SetGameInstance(game)
 
     // Create troop selection UI
-    game.TroopSelection = clashgame.NewTroopSelectionSystem()
+    game.TroopSelection = clashgame.NewTroopSelectionSystem(catalog)
 
     // Create enhanced troop drawer
     game.TroopDrawer = clashgame.NewEnhancedTroopDrawer(game)
 
+    // Map editor, opened with E
+    game.Editor = clashgame.NewTilemapEditor()
+
     // Initialize game state
     game.GameTime = 0
     game.Running = true
     game.StopChannel = make(chan bool)
 
-    // Start the game loop in a goroutine with the tilemap CSV path
-    clashgame.StartGameLoop(game, tilemapCsvPath)
+    // Start the game loop in a goroutine
+    clashgame.StartGameLoop(game)
+
+    // Pick up CSV edits in the data directory while the game runs; troops
+    // already on the field keep their stats
+    if dir != "" {
+        watcher := clashgame.WatchCatalog(game, data, time.Second)
+        defer watcher.Stop()
+    }
 
     // Run the game
     if err := ebiten.RunGame(game); err != nil {